@@ -3,26 +3,47 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/fastprodman/EntainHW/cmd/migrator/migrations"
 	"github.com/fastprodman/EntainHW/internal/api"
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
 	"github.com/fastprodman/EntainHW/internal/infra/logging"
-	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/reload"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
 	"github.com/fastprodman/EntainHW/internal/services/balance"
 	"github.com/fastprodman/EntainHW/pkg/envconf"
 	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
 )
 
+const (
+	// cleanupShare keeps 1/cleanupShare of the shutdown timeout for the steps
+	// after the HTTP server.
+	cleanupShare = 5
+	// drainSlack lets the drain wait end before its own timeout does.
+	drainSlack = 100 * time.Millisecond
+)
+
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	err := run(ctx)
+	// Caught from the start and for the whole life of the process, so a
+	// SIGHUP during startup or shutdown is not fatal; it only reloads the
+	// config while the server runs.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	err := run(ctx, os.Args[1:], hup)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error running api: %v", err)
 		//nolint:gocritic
@@ -30,39 +51,140 @@ func main() {
 	}
 }
 
-func run(ctx context.Context) (retErr error) {
-	cfg := new(apiConfig)
+func run(ctx context.Context, args []string, hup <-chan os.Signal) (retErr error) {
+	cfg := new(config.APIConfig)
+
+	report, err := envconf.LoadWithReport(cfg, config.Sources(args)...)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
 
-	err := envconf.Load(cfg)
 	if err != nil {
 		return fmt.Errorf("init config: %w", err)
 	}
 
-	logging.SetupJSON(cfg.LogLevel)
+	var logLevel slog.LevelVar
+
+	logLevel.Set(cfg.LogLevel)
+	logging.SetupJSON(&logLevel)
+	slog.Debug("Config loaded", "sources", report)
+
+	settings, err := envconf.Dump(cfg)
+	if err != nil {
+		return fmt.Errorf("dump config: %w", err)
+	}
+
+	slog.Info("Effective config", "config", settings)
+
+	// Owned by this run, so nothing else in the process shares its shutdown.
+	queue := shutdownqueue.New()
 
 	defer func() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer cancel()
 
-		serr := shutdownqueue.Shutdown(shutdownCtx)
+		report, serr := queue.Shutdown(shutdownCtx)
 		if serr != nil {
 			retErr = errors.Join(retErr, serr)
 		}
+
+		slog.Info("Shutdown finished", "tasks", report)
 	}()
 
+	// Changes to fields tagged reload:"true" are applied on SIGHUP.
+	reloader := reload.New(cfg, func() (*config.APIConfig, error) {
+		next := new(config.APIConfig)
+
+		return next, envconf.Load(next, config.Sources(args)...)
+	})
+	reloader.Subscribe("logging", func(_ context.Context, cfg *config.APIConfig, _ []envconf.Change) error {
+		logLevel.Set(cfg.LogLevel)
+
+		return nil
+	})
+
 	// --- Infra ---
-	dbConns, err := pgutils.OpenDB(ctx, cfg.Postgres)
+	err = tracing.Setup(ctx, cfg.Tracing, queue)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+
+	schemaVersion, err := migrations.LatestVersion()
 	if err != nil {
-		return fmt.Errorf("open db: %w", err)
+		return fmt.Errorf("expected schema version: %w", err)
+	}
+
+	var (
+		balanceSrv balance.BalanceService
+		checks     []health.Check
+	)
+
+	switch cfg.Storage {
+	case config.StorageMemory:
+		balanceSrv = openMemoryStorage()
+
+		slog.Warn("Storage is in memory; all state is lost on exit")
+
+	case config.StoragePostgres, "":
+		if cfg.Postgres.DSN == "" {
+			return errMissingDSN
+		}
+
+		var storageOpts []balance.Option
+
+		// The cache relies on Postgres notifications, so it is Postgres only.
+		if cfg.BalanceCache.Enabled {
+			c := startBalanceCache(ctx, queue, cfg.BalanceCache, cfg.Postgres.DSN)
+			storageOpts = append(storageOpts, balance.WithCache(c))
+		}
+
+		balanceSrv, checks, err = openStorage(ctx, queue, cfg.Postgres, reloader, schemaVersion, storageOpts...)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Storage opened",
+			"driver", cfg.Postgres.Driver,
+			"balance_cache", cfg.BalanceCache.Enabled,
+		)
+
+	default:
+		return fmt.Errorf("%w: %q", errUnknownStorage, cfg.Storage)
 	}
 
-	balanceSrv := balance.New(dbConns)
+	probes := health.New(cfg.ReadyTimeout, checks...)
 
 	// --- HTTP server ---
-	srv := api.NewServer(cfg.Port, balanceSrv)
+	var apiOpts []api.Option
+
+	if cfg.CaptureFile != "" {
+		rec, err := capture.Open(cfg.CaptureFile)
+		if err != nil {
+			return err
+		}
+
+		// Registered before the server so it closes after the last request.
+		queue.Add(func(context.Context) error {
+			slog.Info("Close capture file")
+
+			return rec.Close()
+		}, shutdownqueue.WithName("capture file"))
+
+		apiOpts = append(apiOpts, api.WithCapture(rec))
+
+		slog.Warn("Capturing transaction requests", "file", cfg.CaptureFile)
+	}
+
+	srv := api.NewServer(cfg.Port, balanceSrv, probes, apiOpts...)
+
+	drainBudget, serverBudget := shutdownBudgets(cfg.ShutdownTimeout, cfg.DrainDelay)
+	if drainBudget < cfg.DrainDelay {
+		slog.Warn("Drain delay cut to fit the shutdown timeout",
+			"drain_delay", cfg.DrainDelay, "shutdown_timeout", cfg.ShutdownTimeout, "drain", drainBudget)
+	}
 
 	// Register HTTP server graceful shutdown
-	shutdownqueue.Add(func(c context.Context) error {
+	queue.Add(func(c context.Context) error {
 		slog.Info("Shut down server")
 
 		err := srv.Shutdown(c)
@@ -71,7 +193,21 @@ func run(ctx context.Context) (retErr error) {
 		}
 
 		return nil
-	})
+	}, shutdownqueue.WithName("http server"), shutdownqueue.WithTimeout(serverBudget))
+
+	// Registered last so it runs first: fail readiness and give load
+	// balancers time to stop routing to us before the server shuts down.
+	queue.Add(func(c context.Context) error {
+		slog.Info("Draining traffic", "delay", drainBudget)
+		probes.MarkDraining()
+
+		select {
+		case <-time.After(drainBudget):
+			return nil
+		case <-c.Done():
+			return fmt.Errorf("drain: %w", c.Err())
+		}
+	}, shutdownqueue.WithName("drain"), shutdownqueue.WithTimeout(drainBudget+drainSlack))
 
 	// Run server
 	errCh := make(chan error, 1)
@@ -87,12 +223,15 @@ func run(ctx context.Context) (retErr error) {
 		errCh <- nil
 	}()
 
+	go reloader.Watch(ctx, hup)
+
+	probes.MarkStarted()
 	slog.Info("API started")
 
 	// --- Wait until either context cancels or server errors out ---
 	select {
 	case <-ctx.Done():
-		// graceful path; deferred shutdownqueue.Shutdown will run
+		// graceful path; the deferred queue.Shutdown will run
 		return nil
 	case serr := <-errCh:
 		if serr != nil {
@@ -102,3 +241,16 @@ func run(ctx context.Context) (retErr error) {
 		return nil
 	}
 }
+
+// shutdownBudgets splits the shutdown timeout so the steps after the HTTP
+// server (cache listener, pools, tracer) always keep a share of it. Of the
+// rest, drain gets its delay but no more than a third, and the server the
+// remainder.
+func shutdownBudgets(total, drainDelay time.Duration) (drain, server time.Duration) {
+	reserve := total / cleanupShare
+
+	drain = min(drainDelay, (total-reserve)/3)
+	server = total - reserve - drain
+
+	return drain, server
+}
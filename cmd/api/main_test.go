@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+// unsetenv unsets key for the duration of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+
+	t.Setenv(key, "")
+
+	err := os.Unsetenv(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+}
+
+// Memory mode is the single binary, no database setup: it must start with
+// no PG_DSN anywhere.
+//
+//nolint:paralleltest // modifies the environment
+func TestRun_MemoryWithoutDSN(t *testing.T) {
+	for _, key := range []string{"PG_DSN", "PG_DSN_FILE", "ENV_FILE", "CONFIG_FILE"} {
+		unsetenv(t, key)
+	}
+
+	port := freePort(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- run(ctx, []string{"-storage", "memory", "-api-port", strconv.Itoa(port)}, nil)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/readyz", port)
+
+	for {
+		select {
+		case err := <-done:
+			cancel()
+			t.Fatalf("run returned before serving: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		resp, err := http.Get(url) //nolint:noctx
+		if err == nil {
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				break
+			}
+		}
+	}
+
+	cancel()
+
+	err := <-done
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+//nolint:paralleltest // modifies the environment
+func TestRun_PostgresNeedsDSN(t *testing.T) {
+	for _, key := range []string{"PG_DSN", "PG_DSN_FILE", "ENV_FILE", "CONFIG_FILE"} {
+		unsetenv(t, key)
+	}
+
+	err := run(t.Context(), []string{"-storage", "postgres"}, nil)
+	if !errors.Is(err, envconf.ErrMissingRequired) {
+		t.Fatalf("err = %v, want ErrMissingRequired", err)
+	}
+}
+
+func TestShutdownBudgets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		total      time.Duration
+		drainDelay time.Duration
+		wantDrain  time.Duration
+		wantServer time.Duration
+	}{
+		{name: "no_drain", total: 5 * time.Second, wantServer: 4 * time.Second},
+		{
+			name:  "drain_fits",
+			total: 10 * time.Second, drainDelay: 2 * time.Second,
+			wantDrain: 2 * time.Second, wantServer: 6 * time.Second,
+		},
+		{
+			name:  "drain_capped",
+			total: 6 * time.Second, drainDelay: time.Minute,
+			wantDrain: 1600 * time.Millisecond, wantServer: 3200 * time.Millisecond,
+		},
+		{name: "no_timeout", total: 0, drainDelay: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			drain, server := shutdownBudgets(tt.total, tt.drainDelay)
+			if drain != tt.wantDrain || server != tt.wantServer {
+				t.Fatalf("shutdownBudgets(%v, %v) = %v, %v; want %v, %v",
+					tt.total, tt.drainDelay, drain, server, tt.wantDrain, tt.wantServer)
+			}
+
+			// The cleanup share is never handed out.
+			if drain+server > tt.total-tt.total/cleanupShare {
+				t.Fatalf("budgets %v + %v eat into the cleanup share of %v", drain, server, tt.total)
+			}
+		})
+	}
+}
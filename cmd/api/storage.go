@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/reload"
+	memtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/memory"
+	memusers "github.com/fastprodman/EntainHW/internal/repos/users/memory"
+	"github.com/fastprodman/EntainHW/internal/services/balance"
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
+)
+
+// poolLimitEnvs are the reloadable pool settings, which only database/sql
+// can apply to an open pool.
+var poolLimitEnvs = []string{
+	"PG_MAX_OPEN_CONNS",
+	"PG_MAX_IDLE_CONNS",
+	"PG_CONN_MAX_IDLE_TIME",
+	"PG_CONN_MAX_LIFETIME",
+}
+
+var (
+	errUnknownDriver  = errors.New("unknown PG_DRIVER")
+	errUnknownStorage = errors.New("unknown STORAGE")
+	errMissingDSN     = fmt.Errorf("%w: PG_DSN (needed with STORAGE=postgres)", envconf.ErrMissingRequired)
+)
+
+// memorySeedUsers are created with a zero balance in memory mode, matching
+// the DEV seed applied by cmd/migrator.
+var memorySeedUsers = []uint64{1, 2, 3}
+
+// openStorage connects the backend selected by PG_DRIVER and returns the
+// balance service on top of it together with its readiness checks.
+//
+//nolint:ireturn
+func openStorage(
+	ctx context.Context,
+	queue *shutdownqueue.Queue,
+	pgConfig *config.PostgresConfig,
+	reloader *reload.Reloader[config.APIConfig],
+	schemaVersion uint,
+	extra ...balance.Option,
+) (balance.BalanceService, []health.Check, error) {
+	opts := []balance.Option{balance.WithTimeouts(pgutils.Timeouts{
+		Query: pgConfig.QueryTimeout,
+		Tx:    pgConfig.TxTimeout,
+	})}
+	opts = append(opts, extra...)
+
+	switch pgConfig.Driver {
+	case config.DriverStdlib, "":
+		db, err := pgutils.OpenDB(ctx, pgConfig, queue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open db: %w", err)
+		}
+
+		reloader.Subscribe("postgres pool", func(_ context.Context, cfg *config.APIConfig, _ []envconf.Change) error {
+			pgutils.SetPoolLimits(db, cfg.Postgres)
+
+			return nil
+		})
+
+		checks := []health.Check{
+			pgutils.PingCheck(db),
+			pgutils.PoolCheck(db),
+			pgutils.SchemaCheck(db, schemaVersion),
+		}
+
+		if pgConfig.ReplicaDSN != "" {
+			replica, err := pgutils.OpenDB(ctx, replicaConfig(pgConfig), queue)
+			if err != nil {
+				return nil, nil, fmt.Errorf("open replica: %w", err)
+			}
+
+			opts = append(opts, balance.WithReplicaDB(replica))
+
+			reloader.Subscribe("postgres replica pool",
+				func(_ context.Context, cfg *config.APIConfig, _ []envconf.Change) error {
+					pgutils.SetPoolLimits(replica, replicaConfig(cfg.Postgres))
+
+					return nil
+				})
+		}
+
+		return balance.New(db, opts...), checks, nil
+
+	case config.DriverPgxPool:
+		pool, err := pgxutils.OpenPool(ctx, pgConfig, queue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open pool: %w", err)
+		}
+
+		// A pgxpool is sized once, when it is created.
+		reloader.Freeze(poolLimitEnvs...)
+
+		checks := []health.Check{
+			pgxutils.PingCheck(pool),
+			pgxutils.PoolCheck(pool),
+			pgxutils.SchemaCheck(pool, schemaVersion),
+		}
+
+		if pgConfig.ReplicaDSN != "" {
+			replica, err := pgxutils.OpenPool(ctx, replicaConfig(pgConfig), queue)
+			if err != nil {
+				return nil, nil, fmt.Errorf("open replica: %w", err)
+			}
+
+			opts = append(opts, balance.WithReplicaPool(replica))
+		}
+
+		return balance.NewPool(pool, opts...), checks, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", errUnknownDriver, pgConfig.Driver)
+	}
+}
+
+// startBalanceCache builds the balance cache and keeps it in step with commits
+// from every instance by listening for balance_changed on the primary.
+// Notifications sent while the listener is down are lost, so the cache is
+// suspended (emptied and bypassed) from the moment it disconnects, and
+// before it first connects, until it is subscribed again.
+func startBalanceCache(
+	ctx context.Context,
+	queue *shutdownqueue.Queue,
+	cacheConfig *config.BalanceCacheConfig,
+	dsn string,
+) *balance.BalanceCache {
+	c := balance.NewBalanceCache(cacheConfig.MaxSize, cacheConfig.TTL)
+	c.Suspend()
+
+	listenCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		pgutils.Listen(listenCtx, dsn, balance.BalanceChangedChannel, c.Resume, c.Suspend, func(payload string) {
+			err := balance.ApplyBalanceChanged(listenCtx, c, payload)
+			if err != nil {
+				slog.WarnContext(listenCtx, "ignoring balance notification", "error", err)
+			}
+		})
+	}()
+
+	queue.Add(func(c context.Context) error {
+		slog.Info("Stop balance cache listener")
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-c.Done():
+			return fmt.Errorf("stop cache listener: %w", c.Err())
+		}
+	}, shutdownqueue.WithName("balance cache listener"))
+
+	return c
+}
+
+// openMemoryStorage returns the balance service over in-memory repos. State
+// lives and dies with the process; there is nothing to check for readiness.
+func openMemoryStorage() balance.BalanceService { //nolint:ireturn
+	usersRepo := memusers.New()
+	for _, id := range memorySeedUsers {
+		usersRepo.Put(id, 0)
+	}
+
+	txnsRepo := memtransactions.New(usersRepo)
+
+	return balance.NewWithRepos(memtx.NewTxManager(), usersRepo, txnsRepo)
+}
+
+// replicaConfig is pgConfig pointed at the replica. Readiness only covers the
+// primary: reads fall back to it whenever the replica is unavailable.
+func replicaConfig(pgConfig *config.PostgresConfig) *config.PostgresConfig {
+	replica := *pgConfig
+	replica.DSN = pgConfig.ReplicaDSN
+
+	return &replica
+}
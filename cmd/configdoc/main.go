@@ -0,0 +1,127 @@
+// Command configdoc generates .env.example and docs/configuration.md from the
+// `desc` tags of the command configs in internal/config. Run it from the
+// repository root after changing a config struct:
+//
+//	go run ./cmd/configdoc
+//
+// -check compares the files instead of writing them and exits non-zero if
+// they are stale.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+// command is one binary and the config struct it loads.
+type command struct {
+	name string
+	cfg  any
+}
+
+var commands = []command{
+	{name: "cmd/api", cfg: new(config.APIConfig)},
+	{name: "cmd/maintenance", cfg: new(config.MaintenanceConfig)},
+	{name: "cmd/migrator", cfg: new(config.MigratorConfig)},
+}
+
+var errStale = errors.New("generated file is stale; run go run ./cmd/configdoc")
+
+func main() {
+	envPath := flag.String("env", ".env.example", "path of the generated .env example")
+	mdPath := flag.String("md", "docs/configuration.md", "path of the generated Markdown reference")
+	check := flag.Bool("check", false, "compare the files instead of writing them")
+	flag.Parse()
+
+	err := run(*envPath, *mdPath, *check)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configdoc: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(envPath, mdPath string, check bool) error {
+	env, md, err := generate(commands)
+	if err != nil {
+		return err
+	}
+
+	for path, want := range map[string][]byte{envPath: env, mdPath: md} {
+		if check {
+			got, err := os.ReadFile(path) //nolint:gosec
+			if err != nil {
+				return fmt.Errorf("read %s: %w", path, err)
+			}
+
+			if !bytes.Equal(got, want) {
+				return fmt.Errorf("%s: %w", path, errStale)
+			}
+
+			continue
+		}
+
+		err := os.WriteFile(path, want, 0o644) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// generate renders the .env example, where each variable appears once under
+// the first command that reads it, and the Markdown reference, with a full
+// table per command.
+func generate(cmds []command) (env, md []byte, err error) {
+	var envBuf, mdBuf bytes.Buffer
+
+	envBuf.WriteString("# Generated by go run ./cmd/configdoc; do not edit.\n")
+	mdBuf.WriteString("# Configuration\n\n")
+	mdBuf.WriteString("<!-- Generated by go run ./cmd/configdoc; do not edit. -->\n\n")
+	mdBuf.WriteString("Every variable can also be set with the flag of the same name in lower\n")
+	mdBuf.WriteString("case with dashes (`PG_DSN` is `-pg-dsn`), in the `CONFIG_FILE` YAML or\n")
+	mdBuf.WriteString("JSON file, in the `ENV_FILE` .env file, or through a `NAME_FILE` variable\n")
+	mdBuf.WriteString("holding the path of a file with the value.\n")
+
+	seen := map[string]bool{}
+
+	for _, c := range cmds {
+		vars, err := envconf.Describe(c.cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("describe %s: %w", c.name, err)
+		}
+
+		var fresh []envconf.Var
+
+		for _, v := range vars {
+			if !seen[v.Env] {
+				seen[v.Env] = true
+				fresh = append(fresh, v)
+			}
+		}
+
+		if len(fresh) > 0 {
+			envBuf.WriteString("\n# ---- " + c.name + " ----\n\n")
+
+			err = envconf.WriteDotEnv(&envBuf, fresh)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		mdBuf.WriteString("\n## " + c.name + "\n\n")
+
+		err = envconf.WriteMarkdown(&mdBuf, vars)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return envBuf.Bytes(), mdBuf.Bytes(), nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestGeneratedFilesUpToDate fails when a config struct changed without
+// regenerating the files.
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	t.Parallel()
+
+	env, md, err := generate(commands)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	for path, want := range map[string][]byte{"../../.env.example": env, "../../docs/configuration.md": md} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is stale; run go run ./cmd/configdoc", path)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// outcome is what a response says about whether its op changed a balance.
+type outcome int
+
+// checkReport is the result of the invariant checks. Inconclusive checks
+// could not be decided because some outcomes stayed unknown.
+type checkReport struct {
+	violations   []string
+	inconclusive []string
+}
+
+const (
+	outcomeRejected outcome = iota
+	outcomeApplied
+	// outcomeUnknown: no response, or a 5xx; the op may or may not have
+	// been applied.
+	outcomeUnknown
+)
+
+const codeDuplicate = "DUPLICATE_TRANSACTION"
+
+func classify(r result) outcome {
+	switch {
+	case r.err != nil || r.status >= http.StatusInternalServerError:
+		return outcomeUnknown
+	case r.status == http.StatusOK:
+		return outcomeApplied
+	default:
+		return outcomeRejected
+	}
+}
+
+// reconcile resends, once, every valid op that has no applied response and at
+// least one unknown one. Resending is safe: the API is idempotent by
+// transactionId. A 200 means the resend applied it, DUPLICATE_TRANSACTION
+// that an earlier attempt did.
+func reconcile(ctx context.Context, c *client, results []result) map[string]outcome {
+	applied := map[string]bool{}
+	unknown := map[string]op{}
+
+	for _, r := range results {
+		if r.op.kind == kindInvalid {
+			continue
+		}
+
+		switch classify(r) {
+		case outcomeApplied:
+			applied[r.op.txid] = true
+		case outcomeUnknown:
+			unknown[r.op.txid] = r.op
+		case outcomeRejected:
+		}
+	}
+
+	out := map[string]outcome{}
+
+	for txid, o := range unknown {
+		if applied[txid] {
+			continue
+		}
+
+		r := c.send(ctx, o)
+
+		switch {
+		case classify(r) == outcomeApplied || r.code == codeDuplicate:
+			out[txid] = outcomeApplied
+		default:
+			out[txid] = classify(r)
+		}
+	}
+
+	return out
+}
+
+// check verifies, from the client's point of view:
+//   - no balance is negative;
+//   - every transactionId was applied at most once;
+//   - invalid requests were never accepted;
+//   - each final balance equals the initial one plus every applied delta.
+func check(
+	initial, final map[uint64]money.Amount,
+	results []result,
+	reconciled map[string]outcome,
+) checkReport {
+	var rep checkReport
+
+	type txState struct {
+		op      op
+		applied int
+		unknown bool
+	}
+
+	txs := map[string]*txState{}
+
+	for _, r := range results {
+		if r.op.kind == kindInvalid {
+			if r.status >= 200 && r.status < 300 {
+				rep.violations = append(rep.violations,
+					fmt.Sprintf("invalid request %s accepted with %d", describeOp(r.op), r.status))
+			}
+
+			continue
+		}
+
+		st, ok := txs[r.op.txid]
+		if !ok {
+			st = &txState{op: r.op}
+			txs[r.op.txid] = st
+		}
+
+		switch classify(r) {
+		case outcomeApplied:
+			st.applied++
+		case outcomeUnknown:
+			st.unknown = true
+		case outcomeRejected:
+		}
+	}
+
+	expected := maps.Clone(initial)
+	unresolved := map[uint64]int{}
+
+	for txid, st := range txs {
+		if st.applied > 1 {
+			rep.violations = append(rep.violations,
+				fmt.Sprintf("transaction %s applied %d times", txid, st.applied))
+		}
+
+		applied := st.applied > 0
+
+		if !applied && st.unknown {
+			switch reconciled[txid] {
+			case outcomeApplied:
+				applied = true
+			case outcomeUnknown:
+				unresolved[st.op.userID]++
+			case outcomeRejected:
+			}
+		}
+
+		if applied {
+			sum, err := expected[st.op.userID].Add(st.op.delta)
+			if err != nil {
+				rep.violations = append(rep.violations, fmt.Sprintf("user %d: expected balance overflows", st.op.userID))
+
+				continue
+			}
+
+			expected[st.op.userID] = sum
+		}
+	}
+
+	users := make([]uint64, 0, len(final))
+	for id := range final {
+		users = append(users, id)
+	}
+
+	slices.Sort(users)
+
+	for _, id := range users {
+		got := final[id]
+
+		if got < 0 {
+			rep.violations = append(rep.violations, fmt.Sprintf("user %d: negative balance %s", id, got))
+		}
+
+		if n := unresolved[id]; n > 0 {
+			rep.inconclusive = append(rep.inconclusive,
+				fmt.Sprintf("user %d: balance not checked, %d transactions with unknown outcome", id, n))
+
+			continue
+		}
+
+		if got != expected[id] {
+			rep.violations = append(rep.violations,
+				fmt.Sprintf("user %d: final balance %s, want %s (initial %s plus applied transactions)",
+					id, got, expected[id], initial[id]))
+		}
+	}
+
+	return rep
+}
+
+func describeOp(o op) string {
+	return fmt.Sprintf("%s (user %d, source %q, state %q, amount %q)", o.txid, o.userID, o.source, o.state, o.amount)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	win := op{kind: kindWin, userID: 1, txid: "a", delta: 500}
+	lose := op{kind: kindLose, userID: 1, txid: "b", delta: -200}
+	invalid := op{kind: kindInvalid, userID: 1, txid: "c"}
+
+	tests := []struct {
+		name             string
+		final            money.Amount
+		results          []result
+		reconciled       map[string]outcome
+		wantViolations   int
+		wantInconclusive int
+	}{
+		{
+			name:  "consistent",
+			final: 1300,
+			results: []result{
+				{op: win, status: http.StatusOK},
+				{op: lose, status: http.StatusOK},
+				{op: win, status: http.StatusConflict, code: codeDuplicate},
+				{op: invalid, status: http.StatusBadRequest},
+			},
+		},
+		{
+			name:  "rejected op not counted",
+			final: 1500,
+			results: []result{
+				{op: win, status: http.StatusOK},
+				{op: lose, status: http.StatusUnprocessableEntity},
+			},
+		},
+		{
+			name:  "duplicate applied twice",
+			final: 2000,
+			results: []result{
+				{op: win, status: http.StatusOK},
+				{op: win, status: http.StatusOK},
+			},
+			wantViolations: 2,
+		},
+		{
+			name:           "invalid accepted",
+			final:          1000,
+			results:        []result{{op: invalid, status: http.StatusOK}},
+			wantViolations: 1,
+		},
+		{
+			name:           "balance mismatch",
+			final:          1400,
+			results:        []result{{op: win, status: http.StatusOK}},
+			wantViolations: 1,
+		},
+		{
+			name:           "negative balance",
+			final:          -200,
+			results:        []result{{op: lose, status: http.StatusBadRequest}},
+			wantViolations: 2,
+		},
+		{
+			name:       "unknown reconciled as applied",
+			final:      1500,
+			results:    []result{{op: win, err: errors.New("timeout")}},
+			reconciled: map[string]outcome{"a": outcomeApplied},
+		},
+		{
+			name:             "unknown stays unknown",
+			final:            1234,
+			results:          []result{{op: win, status: http.StatusBadGateway}},
+			reconciled:       map[string]outcome{"a": outcomeUnknown},
+			wantInconclusive: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rep := check(
+				map[uint64]money.Amount{1: 1000},
+				map[uint64]money.Amount{1: tt.final},
+				tt.results,
+				tt.reconciled,
+			)
+
+			if len(rep.violations) != tt.wantViolations {
+				t.Errorf("violations = %q, want %d", rep.violations, tt.wantViolations)
+			}
+
+			if len(rep.inconclusive) != tt.wantInconclusive {
+				t.Errorf("inconclusive = %q, want %d", rep.inconclusive, tt.wantInconclusive)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{p: 50, want: 50 * time.Millisecond},
+		{p: 90, want: 90 * time.Millisecond},
+		{p: 99, want: 99 * time.Millisecond},
+		{p: 100, want: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %s, want %s", tt.p, got, tt.want)
+		}
+	}
+
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(nil) = %s, want 0", got)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// result is the response to one op. err is set when no response arrived.
+type result struct {
+	op      op
+	status  int
+	code    string
+	err     error
+	latency time.Duration
+}
+
+func newClient(baseURL string, timeout time.Duration) *client {
+	return &client{baseURL: baseURL, http: &http.Client{Timeout: timeout}}
+}
+
+func (c *client) send(ctx context.Context, o op) result {
+	body, err := json.Marshal(map[string]string{
+		"state":         o.state,
+		"amount":        o.amount,
+		"transactionId": o.txid,
+	})
+	if err != nil {
+		return result{op: o, err: fmt.Errorf("marshal: %w", err)}
+	}
+
+	u := fmt.Sprintf("%s/user/%d/transaction", c.baseURL, o.userID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return result{op: o, err: fmt.Errorf("new request: %w", err)}
+	}
+
+	req.Header.Set("Source-Type", o.source)
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return result{op: o, err: err, latency: time.Since(start)}
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+
+	return result{op: o, status: resp.StatusCode, code: errorCode(b), latency: latency}
+}
+
+func (c *client) balances(ctx context.Context, users []uint64) (map[uint64]money.Amount, error) {
+	out := make(map[uint64]money.Amount, len(users))
+
+	for _, id := range users {
+		b, err := c.balance(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("user %d: %w", id, err)
+		}
+
+		out[id] = b
+	}
+
+	return out, nil
+}
+
+func (c *client) balance(ctx context.Context, userID uint64) (money.Amount, error) {
+	u := fmt.Sprintf("%s/user/%d/balance", c.baseURL, userID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("get balance: %w", err)
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("get balance: status %d %s", resp.StatusCode, errorCode(b))
+	}
+
+	var payload struct {
+		Balance money.Amount `json:"balance"`
+	}
+
+	err = json.Unmarshal(b, &payload)
+	if err != nil {
+		return 0, fmt.Errorf("decode balance: %w", err)
+	}
+
+	return payload.Balance, nil
+}
+
+// errorCode extracts error.code from an error envelope, or "".
+func errorCode(body []byte) string {
+	var env struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+
+	_ = json.Unmarshal(body, &env)
+
+	return env.Error.Code
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// kind is the category of a generated request.
+type kind string
+
+// mix maps request kinds to relative weights.
+type mix map[kind]int
+
+// op is one request to send. Duplicates repeat an earlier op verbatim.
+type op struct {
+	kind   kind
+	userID uint64
+	txid   string
+	source string
+	state  string
+	amount string
+	// delta is the balance change if the op is applied.
+	delta money.Amount
+}
+
+// generator produces ops; it is only used from the driving goroutine.
+type generator struct {
+	rnd       *rand.Rand
+	users     []uint64
+	mix       mix
+	kinds     []kind
+	maxAmount money.Amount
+	sent      []op
+	seq       int
+	runID     string
+}
+
+const (
+	kindWin       kind = "win"
+	kindLose      kind = "lose"
+	kindDuplicate kind = "duplicate"
+	kindInvalid   kind = "invalid"
+)
+
+var allKinds = []kind{kindWin, kindLose, kindDuplicate, kindInvalid}
+
+// invalidOps are requests the API must reject with 400.
+var invalidOps = []op{
+	{source: "game", state: "draw", amount: "1.00"},
+	{source: "game", state: "win", amount: "1.234"},
+	{source: "game", state: "win", amount: "-1.00"},
+	{source: "casino", state: "win", amount: "1.00"},
+}
+
+func parseMix(spec string) (mix, error) {
+	m := mix{}
+
+	for _, part := range strings.Split(spec, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not kind=weight", part)
+		}
+
+		k := kind(name)
+		if !slices.Contains(allKinds, k) {
+			return nil, fmt.Errorf("unknown kind %q", name)
+		}
+
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s", weight, name)
+		}
+
+		m[k] = w
+	}
+
+	total := 0
+	for _, w := range m {
+		total += w
+	}
+
+	if total == 0 {
+		return nil, errors.New("all weights are zero")
+	}
+
+	return m, nil
+}
+
+func (m mix) String() string {
+	parts := make([]string, 0, len(m))
+
+	for _, k := range allKinds {
+		if w, ok := m[k]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%d", k, w))
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func newGenerator(rnd *rand.Rand, cfg config) *generator {
+	g := &generator{
+		rnd:       rnd,
+		users:     cfg.users,
+		mix:       cfg.mix,
+		maxAmount: cfg.maxAmount,
+		runID:     strconv.FormatUint(rnd.Uint64()%1e8, 36),
+	}
+
+	for _, k := range allKinds {
+		for range g.mix[k] {
+			g.kinds = append(g.kinds, k)
+		}
+	}
+
+	return g
+}
+
+func (g *generator) next() op {
+	k := g.kinds[g.rnd.IntN(len(g.kinds))]
+	if k == kindDuplicate && len(g.sent) == 0 {
+		k = kindWin
+	}
+
+	switch k {
+	case kindDuplicate:
+		o := g.sent[g.rnd.IntN(len(g.sent))]
+		o.kind = kindDuplicate
+
+		return o
+
+	case kindInvalid:
+		o := invalidOps[g.rnd.IntN(len(invalidOps))]
+		o.kind = kindInvalid
+		o.userID = g.user()
+		o.txid = g.txid()
+
+		return o
+
+	default:
+		amount := money.Amount(1 + g.rnd.Int64N(int64(g.maxAmount)))
+		o := op{
+			kind:   k,
+			userID: g.user(),
+			txid:   g.txid(),
+			source: "game",
+			state:  string(k),
+			amount: amount.String(),
+			delta:  amount,
+		}
+
+		if k == kindLose {
+			o.delta = -amount
+		}
+
+		g.sent = append(g.sent, o)
+
+		return o
+	}
+}
+
+func (g *generator) user() uint64 {
+	return g.users[g.rnd.IntN(len(g.users))]
+}
+
+func (g *generator) txid() string {
+	g.seq++
+
+	return fmt.Sprintf("loadgen-%s-%d", g.runID, g.seq)
+}
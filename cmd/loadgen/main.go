@@ -0,0 +1,201 @@
+// Command loadgen drives a mix of wins, losses, duplicates and invalid
+// requests against a running API at a target rate, then checks that the
+// service kept its invariants and prints latency percentiles and an error
+// breakdown. It exits non-zero if an invariant was violated.
+//
+// The balance check assumes nothing else writes to the chosen users during
+// the run.
+//
+//	go run ./cmd/loadgen -url http://localhost:8080 -rps 30 -duration 1m -users 1,2,3
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+type config struct {
+	baseURL     string
+	rps         float64
+	duration    time.Duration
+	concurrency int
+	timeout     time.Duration
+	users       []uint64
+	mix         mix
+	maxAmount   money.Amount
+	seed        uint64
+}
+
+var errViolations = errors.New("invariants violated")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		os.Exit(2)
+	}
+
+	err = run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		//nolint:gocritic
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (config, error) {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+
+	var (
+		cfg       config
+		users     string
+		mixSpec   string
+		maxAmount string
+	)
+
+	fs.StringVar(&cfg.baseURL, "url", "http://localhost:8080", "API base URL")
+	fs.Float64Var(&cfg.rps, "rps", 25, "target requests per second")
+	fs.DurationVar(&cfg.duration, "duration", 30*time.Second, "how long to send traffic")
+	fs.IntVar(&cfg.concurrency, "concurrency", 32, "max requests in flight")
+	fs.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "per-request timeout")
+	fs.StringVar(&users, "users", "1,2,3", "comma-separated user IDs to spread traffic over; they must exist")
+	fs.StringVar(&mixSpec, "mix", "win=50,lose=35,duplicate=10,invalid=5", "relative weights of request kinds")
+	fs.StringVar(&maxAmount, "max-amount", "20.00", "upper bound of a random win/lose amount")
+	fs.Uint64Var(&cfg.seed, "seed", uint64(time.Now().UnixNano()), "random seed, for reproducible runs")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return config{}, err //nolint:wrapcheck
+	}
+
+	if cfg.rps <= 0 || cfg.concurrency <= 0 {
+		return config{}, errors.New("-rps and -concurrency must be positive")
+	}
+
+	for _, s := range strings.Split(users, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil || id == 0 {
+			return config{}, fmt.Errorf("-users: invalid user ID %q", s)
+		}
+
+		cfg.users = append(cfg.users, id)
+	}
+
+	cfg.mix, err = parseMix(mixSpec)
+	if err != nil {
+		return config{}, fmt.Errorf("-mix: %w", err)
+	}
+
+	cfg.maxAmount, err = money.Parse(maxAmount)
+	if err != nil || cfg.maxAmount <= 0 {
+		return config{}, fmt.Errorf("-max-amount: invalid amount %q", maxAmount)
+	}
+
+	return cfg, nil
+}
+
+func run(ctx context.Context, cfg config) error {
+	c := newClient(cfg.baseURL, cfg.timeout)
+
+	initial, err := c.balances(ctx, cfg.users)
+	if err != nil {
+		return fmt.Errorf("initial balances: %w", err)
+	}
+
+	fmt.Printf("loadgen: %.1f rps for %s over users %v, mix %s, seed %d\n",
+		cfg.rps, cfg.duration, cfg.users, cfg.mix, cfg.seed)
+
+	gen := newGenerator(rand.New(rand.NewPCG(cfg.seed, cfg.seed)), cfg) //nolint:gosec
+	results, skipped, elapsed := drive(ctx, cfg, c, gen)
+
+	// Resolve requests whose outcome is unknown before reading final
+	// balances, so the balance check can account for them.
+	reconciled := reconcile(context.WithoutCancel(ctx), c, results)
+
+	final, err := c.balances(context.WithoutCancel(ctx), cfg.users)
+	if err != nil {
+		return fmt.Errorf("final balances: %w", err)
+	}
+
+	rep := check(initial, final, results, reconciled)
+	printReport(os.Stdout, results, skipped, elapsed, rep)
+
+	if len(rep.violations) > 0 {
+		return fmt.Errorf("%w: %d", errViolations, len(rep.violations))
+	}
+
+	return nil
+}
+
+// drive sends one generated request per tick until the duration elapses or
+// ctx is done. A tick that finds every worker busy is skipped and counted
+// rather than queued, so a slow API shows up as missed rate.
+func drive(ctx context.Context, cfg config, c *client, gen *generator) ([]result, int, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	interval := time.Duration(float64(time.Second) / cfg.rps)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []result
+		skipped int
+	)
+
+	slots := make(chan struct{}, cfg.concurrency)
+	start := time.Now()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-tick.C:
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			skipped++
+
+			continue
+		}
+
+		o := gen.next()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			r := c.send(context.WithoutCancel(ctx), o)
+
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return results, skipped, time.Since(start)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// printReport writes throughput, latency percentiles per kind, the response
+// breakdown and the invariant check results to w.
+func printReport(w io.Writer, results []result, skipped int, elapsed time.Duration, rep checkReport) {
+	fmt.Fprintf(w, "\nsent %d requests in %s (%.1f rps), %d ticks skipped at the concurrency limit\n",
+		len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds(), skipped)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "\nkind\tcount\tp50\tp90\tp99\tmax")
+
+	latencies := map[kind][]time.Duration{}
+	for _, r := range results {
+		latencies[r.op.kind] = append(latencies[r.op.kind], r.latency)
+	}
+
+	var all []time.Duration
+
+	for _, k := range allKinds {
+		if ls := latencies[k]; len(ls) > 0 {
+			writeLatencyRow(tw, string(k), ls)
+			all = append(all, ls...)
+		}
+	}
+
+	if len(all) > 0 {
+		writeLatencyRow(tw, "all", all)
+	}
+
+	fmt.Fprintln(tw, "\nkind\tresponse\tcount")
+
+	for _, row := range breakdown(results) {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", row.kind, row.response, row.count)
+	}
+
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+
+	for _, s := range rep.inconclusive {
+		fmt.Fprintf(w, "INCONCLUSIVE %s\n", s)
+	}
+
+	for _, s := range rep.violations {
+		fmt.Fprintf(w, "VIOLATION %s\n", s)
+	}
+
+	if len(rep.violations) == 0 {
+		fmt.Fprintln(w, "invariants hold")
+	}
+}
+
+func writeLatencyRow(w io.Writer, name string, ls []time.Duration) {
+	sorted := slices.Clone(ls)
+	slices.Sort(sorted)
+
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", name, len(sorted),
+		percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99), sorted[len(sorted)-1].Round(time.Microsecond))
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+
+	return sorted[max(rank, 1)-1].Round(time.Microsecond)
+}
+
+type breakdownRow struct {
+	kind     kind
+	response string
+	count    int
+}
+
+// breakdown counts responses per kind as "status CODE", or the transport
+// error for requests that got none.
+func breakdown(results []result) []breakdownRow {
+	counts := map[kind]map[string]int{}
+
+	for _, r := range results {
+		resp := strconv.Itoa(r.status)
+
+		switch {
+		case r.err != nil:
+			resp = "error: " + r.err.Error()
+		case r.code != "":
+			resp += " " + r.code
+		}
+
+		if counts[r.op.kind] == nil {
+			counts[r.op.kind] = map[string]int{}
+		}
+
+		counts[r.op.kind][resp]++
+	}
+
+	var rows []breakdownRow
+
+	for _, k := range allKinds {
+		for resp, n := range counts[k] {
+			rows = append(rows, breakdownRow{kind: k, response: resp, count: n})
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b breakdownRow) int {
+		if a.kind != b.kind {
+			return slices.Index(allKinds, a.kind) - slices.Index(allKinds, b.kind)
+		}
+
+		return b.count - a.count
+	})
+
+	return rows
+}
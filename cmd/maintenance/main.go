@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/logging"
+	"github.com/fastprodman/EntainHW/internal/services/maintenance"
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx)
+	if err != nil {
+		slog.Error("maintenance failed", "error", err)
+		//nolint:gocritic
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context) error {
+	cfg := new(config.MaintenanceConfig)
+
+	report, err := envconf.LoadWithReport(cfg, config.Sources(os.Args[1:])...)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	logging.SetupJSON(cfg.LogLevel)
+	slog.Debug("Config loaded", "sources", report)
+
+	// Ids of records still in the live table must stay reserved.
+	if p := cfg.Partitions; p.KeyRetention > 0 && p.KeyRetention < p.IdempotencyWindow {
+		return fmt.Errorf("%w: TX_KEY_RETENTION %s is shorter than TX_IDEMPOTENCY_WINDOW %s",
+			envconf.ErrInvalidValue, p.KeyRetention, p.IdempotencyWindow)
+	}
+
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	//nolint:errcheck
+	defer db.Close()
+
+	m := maintenance.New(db, cfg.Partitions)
+
+	if cfg.Interval <= 0 {
+		_, err = m.Run(ctx)
+
+		return err //nolint:wrapcheck
+	}
+
+	slog.Info("Maintenance scheduled", "interval", cfg.Interval)
+
+	for {
+		// A failed pass is retried on the next tick; future partitions are
+		// created months in advance, so a few misses are harmless.
+		_, err = m.Run(ctx)
+		if err != nil {
+			slog.Error("maintenance pass failed", "error", err)
+		}
+
+		select {
+		case <-time.After(cfg.Interval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
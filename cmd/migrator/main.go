@@ -2,12 +2,15 @@ package main
 
 import (
 	"database/sql"
-	"embed"
 	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 
+	"github.com/fastprodman/EntainHW/cmd/migrator/migrations"
+	"github.com/fastprodman/EntainHW/internal/config"
 	"github.com/fastprodman/EntainHW/internal/infra/logging"
 	"github.com/fastprodman/EntainHW/pkg/envconf"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -18,15 +21,6 @@ import (
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-//go:embed migrations/*.sql
-var baseFS embed.FS
-
-type migratorConfig struct {
-	DSN      string     `env:"PG_DSN"`
-	LogLevel slog.Level `env:"APP_LOG_LEVEL"`
-	AppEnv   string     `env:"APP_ENV"`
-}
-
 func main() {
 	err := migrateAll()
 	if err != nil {
@@ -38,14 +32,26 @@ func main() {
 }
 
 func migrateAll() error {
-	cfg := new(migratorConfig)
+	cfg := new(config.MigratorConfig)
+
+	report, err := envconf.LoadWithReport(cfg, config.Sources(os.Args[1:])...)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
 
-	err := envconf.Load(cfg)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
 	logging.SetupJSON(cfg.LogLevel)
+	slog.Debug("Config loaded", "sources", report)
+
+	settings, err := envconf.Dump(cfg)
+	if err != nil {
+		return fmt.Errorf("dump config: %w", err)
+	}
+
+	slog.Info("Effective config", "config", settings)
 
 	db, err := sql.Open("postgres", cfg.DSN)
 	if err != nil {
@@ -64,7 +70,7 @@ func migrateAll() error {
 		return fmt.Errorf("init postgres driver: %w", err)
 	}
 
-	err = runMigrations(driver, baseFS, "migrations")
+	err = runMigrations(driver, migrations.FS, ".")
 	if err != nil {
 		return fmt.Errorf("base migrations failed: %w", err)
 	}
@@ -95,7 +101,7 @@ func migrateAll() error {
 	return nil
 }
 
-func runMigrations(driver database.Driver, fsys embed.FS, dir string) error {
+func runMigrations(driver database.Driver, fsys fs.FS, dir string) error {
 	src, err := iofs.New(fsys, dir)
 	if err != nil {
 		return fmt.Errorf("iofs source: %w", err)
@@ -0,0 +1,49 @@
+-- Applies a win/lose transaction in a single statement:
+-- idempotency claim, user existence check, non-negative guard and balance
+-- update. Returns the outcome and the user's balance after the call.
+--
+-- out_status is one of: applied | duplicate | user_not_found | insufficient_funds
+CREATE OR REPLACE FUNCTION apply_balance_transaction(
+    p_transaction_id TEXT,
+    p_user_id        BIGINT,
+    p_delta          BIGINT, -- in cents; negative for "lose"
+    OUT out_status   TEXT,
+    OUT new_balance  BIGINT
+) AS $$
+BEGIN
+    -- 1) Claim the transaction id first so a duplicate never touches the balance.
+    INSERT INTO transactions (transaction_id, user_id)
+    SELECT p_transaction_id, p_user_id
+    WHERE EXISTS (SELECT 1 FROM users u WHERE u.id = p_user_id)
+    ON CONFLICT (transaction_id) DO NOTHING;
+
+    IF NOT FOUND THEN
+        IF EXISTS (SELECT 1 FROM transactions t WHERE t.transaction_id = p_transaction_id) THEN
+            out_status := 'duplicate';
+        ELSE
+            out_status := 'user_not_found';
+        END IF;
+
+        RETURN;
+    END IF;
+
+    -- 2) Apply the effect; the row lock serializes concurrent writers per user.
+    UPDATE users u
+    SET balance = u.balance + p_delta
+    WHERE u.id = p_user_id
+      AND u.balance + p_delta >= 0
+    RETURNING u.balance INTO new_balance;
+
+    IF NOT FOUND THEN
+        -- Release the claim so the same id can be retried later.
+        DELETE FROM transactions t WHERE t.transaction_id = p_transaction_id;
+
+        SELECT u.balance INTO new_balance FROM users u WHERE u.id = p_user_id;
+        out_status := 'insufficient_funds';
+
+        RETURN;
+    END IF;
+
+    out_status := 'applied';
+END;
+$$ LANGUAGE plpgsql;
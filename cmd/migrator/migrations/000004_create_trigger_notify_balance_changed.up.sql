@@ -0,0 +1,19 @@
+-- Publishes every committed balance change on the balance_changed channel so
+-- API instances can keep their in-process balance caches fresh.
+--
+-- Payload: '<user id>:<new balance in cents>'. Notifications are delivered
+-- after commit and in commit order, so the last payload seen for a user is
+-- its current balance.
+CREATE OR REPLACE FUNCTION notify_balance_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('balance_changed', NEW.id::text || ':' || NEW.balance::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_notify_balance_changed_trg
+AFTER UPDATE OF balance ON users
+FOR EACH ROW
+WHEN (NEW.balance IS DISTINCT FROM OLD.balance)
+EXECUTE FUNCTION notify_balance_changed();
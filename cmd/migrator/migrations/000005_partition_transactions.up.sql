@@ -0,0 +1,160 @@
+-- Splits transaction bookkeeping in two:
+--
+-- * transaction_keys: one narrow row per transaction id, the only place
+--   duplicates are detected. It is never partitioned, so idempotency holds
+--   for as long as a key is kept, however old the transaction.
+-- * transactions: the full record, range-partitioned by month on created_at.
+--   Partitions older than the idempotency window are detached and moved to
+--   the archive schema by the maintenance job (cmd/maintenance); from then on
+--   only transaction_keys remembers those ids.
+--
+-- Partitioned tables cannot have a unique index that excludes the partition
+-- key, which is why uniqueness moves to transaction_keys.
+
+CREATE SCHEMA IF NOT EXISTS archive;
+
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+
+CREATE TABLE transaction_keys (
+    transaction_id TEXT PRIMARY KEY,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE transactions (
+    transaction_id TEXT        NOT NULL,
+    user_id        BIGINT      NOT NULL REFERENCES users(id),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (transaction_id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Partition names are transactions_YYYY_MM; month boundaries are in UTC.
+CREATE OR REPLACE FUNCTION transaction_partition_name(p_month TIMESTAMP)
+RETURNS TEXT AS $$
+    SELECT 'transactions_' || to_char(p_month, 'YYYY_MM');
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Creates the monthly partitions covering [p_from, p_to] that do not exist
+-- yet and returns their names.
+CREATE OR REPLACE FUNCTION create_transaction_partitions(
+    p_from TIMESTAMPTZ,
+    p_to   TIMESTAMPTZ
+) RETURNS SETOF TEXT AS $$
+DECLARE
+    m    TIMESTAMP := date_trunc('month', p_from AT TIME ZONE 'UTC');
+    name TEXT;
+BEGIN
+    WHILE m <= p_to AT TIME ZONE 'UTC' LOOP
+        name := transaction_partition_name(m);
+
+        IF to_regclass(format('public.%I', name)) IS NULL THEN
+            EXECUTE format(
+                'CREATE TABLE public.%I PARTITION OF transactions FOR VALUES FROM (%L) TO (%L)',
+                name,
+                m AT TIME ZONE 'UTC',
+                (m + interval '1 month') AT TIME ZONE 'UTC'
+            );
+
+            RETURN NEXT name;
+        END IF;
+
+        m := m + interval '1 month';
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Detaches every monthly partition that ends at or before p_before, moves it
+-- to the archive schema and returns its name. Archived tables can be dumped
+-- and dropped at leisure; their ids stay in transaction_keys.
+CREATE OR REPLACE FUNCTION archive_transaction_partitions(p_before TIMESTAMPTZ)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    name TEXT;
+BEGIN
+    FOR name IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'public.transactions'::regclass
+          AND c.relname ~ '^transactions_[0-9]{4}_[0-9]{2}$'
+          AND (to_date(substr(c.relname, 14), 'YYYY_MM')::timestamp + interval '1 month')
+              AT TIME ZONE 'UTC' <= p_before
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format('ALTER TABLE transactions DETACH PARTITION public.%I', name);
+        EXECUTE format('ALTER TABLE public.%I SET SCHEMA archive', name);
+
+        RETURN NEXT name;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Cover the existing history plus a few months ahead, then move it over.
+SELECT create_transaction_partitions(
+    COALESCE((SELECT min(created_at) FROM transactions_unpartitioned), now()),
+    now() + interval '3 months'
+);
+
+INSERT INTO transaction_keys (transaction_id, created_at)
+SELECT transaction_id, created_at FROM transactions_unpartitioned;
+
+INSERT INTO transactions (transaction_id, user_id, created_at)
+SELECT transaction_id, user_id, created_at FROM transactions_unpartitioned;
+
+DROP TABLE transactions_unpartitioned;
+
+CREATE INDEX transactions_user_id_created_at_desc_idx
+    ON transactions (user_id, created_at DESC);
+
+CREATE INDEX transaction_keys_created_at_idx
+    ON transaction_keys (created_at);
+
+-- Same contract as before; the idempotency claim now goes to transaction_keys
+-- and the record is written only once the balance update succeeded.
+CREATE OR REPLACE FUNCTION apply_balance_transaction(
+    p_transaction_id TEXT,
+    p_user_id        BIGINT,
+    p_delta          BIGINT, -- in cents; negative for "lose"
+    OUT out_status   TEXT,
+    OUT new_balance  BIGINT
+) AS $$
+BEGIN
+    -- 1) Claim the transaction id first so a duplicate never touches the balance.
+    INSERT INTO transaction_keys (transaction_id)
+    SELECT p_transaction_id
+    WHERE EXISTS (SELECT 1 FROM users u WHERE u.id = p_user_id)
+    ON CONFLICT (transaction_id) DO NOTHING;
+
+    IF NOT FOUND THEN
+        IF EXISTS (SELECT 1 FROM transaction_keys k WHERE k.transaction_id = p_transaction_id) THEN
+            out_status := 'duplicate';
+        ELSE
+            out_status := 'user_not_found';
+        END IF;
+
+        RETURN;
+    END IF;
+
+    -- 2) Apply the effect; the row lock serializes concurrent writers per user.
+    UPDATE users u
+    SET balance = u.balance + p_delta
+    WHERE u.id = p_user_id
+      AND u.balance + p_delta >= 0
+    RETURNING u.balance INTO new_balance;
+
+    IF NOT FOUND THEN
+        -- Release the claim so the same id can be retried later.
+        DELETE FROM transaction_keys k WHERE k.transaction_id = p_transaction_id;
+
+        SELECT u.balance INTO new_balance FROM users u WHERE u.id = p_user_id;
+        out_status := 'insufficient_funds';
+
+        RETURN;
+    END IF;
+
+    -- 3) Record it.
+    INSERT INTO transactions (transaction_id, user_id)
+    VALUES (p_transaction_id, p_user_id);
+
+    out_status := 'applied';
+END;
+$$ LANGUAGE plpgsql;
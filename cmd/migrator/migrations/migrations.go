@@ -0,0 +1,40 @@
+// Package migrations embeds the schema migrations applied by cmd/migrator so
+// other binaries can tell which schema version they expect.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion returns the highest migration version found in FS.
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var latest uint
+
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse version of %q: %w", e.Name(), err)
+		}
+
+		latest = max(latest, uint(v))
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "testing"
+
+func TestLatestVersion(t *testing.T) {
+	t.Parallel()
+
+	v, err := LatestVersion()
+	if err != nil {
+		t.Fatalf("latest version: %v", err)
+	}
+
+	if v < 2 {
+		t.Fatalf("want at least version 2, got %d", v)
+	}
+}
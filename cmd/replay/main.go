@@ -0,0 +1,121 @@
+// Command replay re-sends transaction requests captured by the API (see
+// CAPTURE_FILE) against another instance, in the captured order, and reports
+// every response that differs from the captured one and every user whose
+// balance moved differently than it did when the traffic was captured.
+// It exits non-zero if anything differs.
+//
+// Balance changes are compared, not absolute balances: the target only needs
+// the captured users to exist, and nothing else should write to them while
+// the replay runs.
+//
+//	go run ./cmd/replay -file capture.jsonl -url http://localhost:8080
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+)
+
+type config struct {
+	file     string
+	baseURL  string
+	speed    float64
+	timeout  time.Duration
+	txPrefix string
+}
+
+var errDifferences = errors.New("replay differs from capture")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		os.Exit(2)
+	}
+
+	err = run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		//nolint:gocritic
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (config, error) {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+
+	var cfg config
+
+	fs.StringVar(&cfg.file, "file", "", "capture file to replay (required)")
+	fs.StringVar(&cfg.baseURL, "url", "http://localhost:8080", "base URL of the instance to replay against")
+	fs.Float64Var(&cfg.speed, "speed", 0,
+		"replay pace relative to the capture: 1 keeps the captured gaps, 2 halves them, 0 sends back to back")
+	fs.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "per-request timeout")
+	fs.StringVar(&cfg.txPrefix, "tx-prefix", "",
+		"prefix every transactionId, so a capture can be replayed more than once against the same data")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return config{}, fmt.Errorf("parse flags: %w", err)
+	}
+
+	switch {
+	case cfg.file == "":
+		return config{}, errors.New("-file is required")
+	case cfg.speed < 0:
+		return config{}, errors.New("-speed must not be negative")
+	}
+
+	return cfg, nil
+}
+
+func run(ctx context.Context, cfg config) error {
+	records, err := capture.ReadFile(cfg.file)
+	if err != nil {
+		return err
+	}
+
+	c := newClient(cfg.baseURL, cfg.timeout)
+	users := capturedUsers(records)
+
+	initial, err := c.balances(ctx, users)
+	if err != nil {
+		return fmt.Errorf("initial balances: %w", err)
+	}
+
+	fmt.Printf("replay: %d requests over %d users from %s against %s\n",
+		len(records), len(users), cfg.file, cfg.baseURL)
+
+	start := time.Now()
+	results := replay(ctx, c, records, cfg.speed, cfg.txPrefix)
+	elapsed := time.Since(start)
+
+	final, err := c.balances(ctx, users)
+	if err != nil {
+		return fmt.Errorf("final balances: %w", err)
+	}
+
+	rep := compare(results, initial, final)
+	printReport(os.Stdout, rep, len(results), elapsed)
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted: %w", ctx.Err())
+	}
+
+	if rep.differs() {
+		return errDifferences
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// result pairs a captured record with what the target answered.
+type result struct {
+	rec    capture.Record
+	line   int
+	status int
+	code   string
+	err    error
+}
+
+// dropHeaders are not replayed: redacted values are useless, the rest
+// belongs to the original connection or trace.
+var dropHeaders = []string{
+	"Content-Length",
+	"Host",
+	"Connection",
+	"Accept-Encoding",
+	"Traceparent",
+	"Tracestate",
+}
+
+func newClient(baseURL string, timeout time.Duration) *client {
+	return &client{baseURL: strings.TrimRight(baseURL, "/"), http: &http.Client{Timeout: timeout}}
+}
+
+// replay sends records in order. With speed > 0 it waits the captured gap
+// between consecutive requests, divided by speed.
+func replay(ctx context.Context, c *client, records []capture.Record, speed float64, txPrefix string) []result {
+	results := make([]result, 0, len(records))
+
+	for i, rec := range records {
+		if speed > 0 && i > 0 {
+			gap := time.Duration(float64(rec.Time.Sub(records[i-1].Time)) / speed)
+			if gap > 0 {
+				select {
+				case <-time.After(gap):
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		r := c.send(ctx, rec, txPrefix)
+		r.line = i + 1
+		results = append(results, r)
+	}
+
+	return results
+}
+
+func (c *client) send(ctx context.Context, rec capture.Record, txPrefix string) result {
+	body := rec.Body
+	if txPrefix != "" {
+		body = prefixTransactionID(body, txPrefix)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, rec.Method, c.baseURL+rec.Path, strings.NewReader(body))
+	if err != nil {
+		return result{rec: rec, err: fmt.Errorf("new request: %w", err)}
+	}
+
+	for name, vs := range rec.Header {
+		if slices.Contains(dropHeaders, name) || slices.Contains(vs, capture.Redacted) {
+			continue
+		}
+
+		req.Header[name] = vs
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return result{rec: rec, err: err}
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+
+	return result{rec: rec, status: resp.StatusCode, code: errorCode(b)}
+}
+
+// prefixTransactionID rewrites transactionId in a JSON body. Bodies that are
+// not a JSON object with a string transactionId are replayed as captured.
+func prefixTransactionID(body, prefix string) string {
+	var fields map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(body), &fields)
+	if err != nil {
+		return body
+	}
+
+	var id string
+
+	err = json.Unmarshal(fields["transactionId"], &id)
+	if err != nil {
+		return body
+	}
+
+	fields["transactionId"], _ = json.Marshal(prefix + id) //nolint:errchkjson
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(fields)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// balances reads the balance of every user. Users the target does not know
+// are left out.
+func (c *client) balances(ctx context.Context, users []uint64) (map[uint64]money.Amount, error) {
+	out := make(map[uint64]money.Amount, len(users))
+
+	for _, id := range users {
+		b, found, err := c.balance(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("user %d: %w", id, err)
+		}
+
+		if found {
+			out[id] = b
+		}
+	}
+
+	return out, nil
+}
+
+func (c *client) balance(ctx context.Context, userID uint64) (money.Amount, bool, error) {
+	u := fmt.Sprintf("%s/user/%d/balance", c.baseURL, userID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("get balance: %w", err)
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("get balance: status %d %s", resp.StatusCode, errorCode(b))
+	}
+
+	var payload struct {
+		Balance money.Amount `json:"balance"`
+	}
+
+	err = json.Unmarshal(b, &payload)
+	if err != nil {
+		return 0, false, fmt.Errorf("decode balance: %w", err)
+	}
+
+	return payload.Balance, true, nil
+}
+
+// errorCode extracts error.code from an error envelope, or "".
+func errorCode(body []byte) string {
+	var env struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+
+	_ = json.Unmarshal(body, &env)
+
+	return env.Error.Code
+}
+
+// pathUserID returns the user of a /user/{userId}/transaction path.
+func pathUserID(path string) (uint64, bool) {
+	path, _, _ = strings.Cut(path, "?")
+
+	rest, ok := strings.CutPrefix(path, "/user/")
+	if !ok {
+		return 0, false
+	}
+
+	idStr, ok := strings.CutSuffix(rest, "/transaction")
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// capturedUsers lists, in ascending order, every user a record targets.
+func capturedUsers(records []capture.Record) []uint64 {
+	var users []uint64
+
+	for _, rec := range records {
+		if id, ok := pathUserID(rec.Path); ok && !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+
+	slices.Sort(users)
+
+	return users
+}
+
+// delta is the balance change rec makes if it is applied.
+func delta(rec capture.Record) (money.Amount, bool) {
+	var body struct {
+		State  string `json:"state"`
+		Amount string `json:"amount"`
+	}
+
+	err := json.Unmarshal([]byte(rec.Body), &body)
+	if err != nil {
+		return 0, false
+	}
+
+	amount, err := money.Parse(body.Amount)
+	if err != nil {
+		return 0, false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(body.State)) {
+	case "win":
+		return amount, true
+	case "lose":
+		neg, err := amount.Neg()
+
+		return neg, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/api"
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	memtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/memory"
+	memusers "github.com/fastprodman/EntainHW/internal/repos/users/memory"
+	"github.com/fastprodman/EntainHW/internal/services/balance"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// newInstance starts an in-memory API whose user 1 holds balance, capturing
+// to rec when it is not nil.
+func newInstance(t *testing.T, balance1 money.Amount, rec *capture.Writer) *httptest.Server {
+	t.Helper()
+
+	users := memusers.New()
+	users.Put(1, balance1)
+	users.Put(2, 0)
+
+	svc := balance.NewWithRepos(memtx.NewTxManager(), users, memtransactions.New(users))
+
+	probes := health.New(time.Second)
+	probes.MarkStarted()
+
+	var opts []api.Option
+	if rec != nil {
+		opts = append(opts, api.WithCapture(rec))
+	}
+
+	srv := httptest.NewServer(api.NewRouter(svc, probes, opts...))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func post(t *testing.T, baseURL, path, body string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, baseURL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = resp.Body.Close()
+}
+
+// captureTraffic records a short session, including a retried transaction
+// and two rejected ones, against an instance where user 1 starts with 10.00.
+func captureTraffic(t *testing.T) []capture.Record {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	src := newInstance(t, 1000, capture.NewWriter(&buf))
+
+	post(t, src.URL, "/user/1/transaction", `{"state":"win","amount":"5.00","transactionId":"a"}`)
+	post(t, src.URL, "/user/1/transaction", `{"state":"lose","amount":"12.50","transactionId":"b"}`)
+	post(t, src.URL, "/user/1/transaction", `{"state":"win","amount":"20.00","transactionId":"e"}`)
+	post(t, src.URL, "/user/1/transaction", `{"state":"lose","amount":"12.50","transactionId":"b"}`)
+	post(t, src.URL, "/user/2/transaction", `{"state":"lose","amount":"1.00","transactionId":"c"}`)
+	post(t, src.URL, "/user/2/transaction", `{"state":"bogus","amount":"1.00","transactionId":"d"}`)
+
+	recs, err := capture.Read(&buf)
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+
+	return recs
+}
+
+func replayAgainst(t *testing.T, records []capture.Record, target *httptest.Server, txPrefix string) report {
+	t.Helper()
+
+	ctx := context.Background()
+	c := newClient(target.URL, time.Second)
+	users := capturedUsers(records)
+
+	initial, err := c.balances(ctx, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := replay(ctx, c, records, 0, txPrefix)
+
+	final, err := c.balances(ctx, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return compare(results, initial, final)
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	records := captureTraffic(t)
+	if len(records) != 6 {
+		t.Fatalf("captured %d records, want 6", len(records))
+	}
+
+	t.Run("same starting state matches", func(t *testing.T) {
+		t.Parallel()
+
+		rep := replayAgainst(t, records, newInstance(t, 1000, nil), "")
+		if rep.differs() {
+			t.Fatalf("replay differs: responses %+v, users %+v", rep.responses, rep.users)
+		}
+
+		if got := rep.users[0]; got.id != 1 || got.observed != 1250 {
+			t.Errorf("user 1 = %+v, want observed +12.50", got)
+		}
+	})
+
+	t.Run("different starting state differs", func(t *testing.T) {
+		t.Parallel()
+
+		// From 1.00 the 12.50 loss is rejected, unlike in the capture, so
+		// its retry is applied instead of being answered as a duplicate.
+		rep := replayAgainst(t, records, newInstance(t, 100, nil), "")
+		if !rep.differs() {
+			t.Fatal("replay matches, want differences")
+		}
+
+		if len(rep.responses) != 2 || rep.responses[0].line != 2 || rep.responses[1].line != 4 {
+			t.Errorf("differing responses = %+v, want lines 2 and 4", rep.responses)
+		}
+
+		if u := rep.users[0]; u.differs() || u.replayed != 1250 {
+			t.Errorf("user 1 = %+v, want the same +12.50 change", u)
+		}
+	})
+
+	t.Run("second replay needs a prefix", func(t *testing.T) {
+		t.Parallel()
+
+		target := newInstance(t, 1000, nil)
+
+		if rep := replayAgainst(t, records, target, ""); rep.differs() {
+			t.Fatalf("first replay differs: %+v", rep.responses)
+		}
+
+		if rep := replayAgainst(t, records, target, ""); !rep.differs() {
+			t.Fatal("second replay without prefix matches, want duplicates")
+		}
+
+		if rep := replayAgainst(t, records, target, "run2-"); rep.differs() {
+			t.Fatalf("prefixed replay differs: %+v", rep.responses)
+		}
+	})
+}
+
+func TestPrefixTransactionID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "rewritten",
+			body: `{"state":"win","amount":"1.00","transactionId":"tx-1"}`,
+			want: `{"amount":"1.00","state":"win","transactionId":"p-tx-1"}`,
+		},
+		{name: "not json", body: `not json`, want: `not json`},
+		{name: "no id", body: `{"state":"win"}`, want: `{"state":"win"}`},
+		{name: "numeric id", body: `{"transactionId":7}`, want: `{"transactionId":7}`},
+	}
+
+	for _, tt := range tests {
+		if got := prefixTransactionID(tt.body, "p-"); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathUserID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path   string
+		want   uint64
+		wantOK bool
+	}{
+		{path: "/user/42/transaction", want: 42, wantOK: true},
+		{path: "/user/42/transaction?x=1", want: 42, wantOK: true},
+		{path: "/user/0/transaction"},
+		{path: "/user/abc/transaction"},
+		{path: "/user/42/balance"},
+	}
+
+	for _, tt := range tests {
+		got, ok := pathUserID(tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("pathUserID(%q) = %d, %v, want %d, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// report is what differs between the capture and the replay.
+type report struct {
+	// responses are the results whose response differs from the captured one.
+	responses []result
+	users     []userDelta
+}
+
+// userDelta is how one user's balance moved: as the captured responses say it
+// did, as the replayed responses say it should have, and as observed.
+type userDelta struct {
+	id       uint64
+	captured money.Amount
+	replayed money.Amount
+	observed money.Amount
+	// found is false when the target does not know the user.
+	found bool
+}
+
+func (r report) differs() bool {
+	return len(r.responses) > 0 || slices.ContainsFunc(r.users, userDelta.differs)
+}
+
+func (u userDelta) differs() bool {
+	return u.found && u.observed != u.captured
+}
+
+func compare(results []result, initial, final map[uint64]money.Amount) report {
+	var rep report
+
+	captured := map[uint64]money.Amount{}
+	replayed := map[uint64]money.Amount{}
+
+	for _, r := range results {
+		if !sameResponse(r) {
+			rep.responses = append(rep.responses, r)
+		}
+
+		id, ok := pathUserID(r.rec.Path)
+		if !ok {
+			continue
+		}
+
+		d, ok := delta(r.rec)
+		if !ok {
+			continue
+		}
+
+		if r.rec.Status == http.StatusOK {
+			captured[id] += d
+		}
+
+		if r.err == nil && r.status == http.StatusOK {
+			replayed[id] += d
+		}
+	}
+
+	users := make([]uint64, 0, len(results))
+	for _, r := range results {
+		if id, ok := pathUserID(r.rec.Path); ok && !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+
+	slices.Sort(users)
+
+	for _, id := range users {
+		start, okStart := initial[id]
+		end, okEnd := final[id]
+
+		rep.users = append(rep.users, userDelta{
+			id:       id,
+			captured: captured[id],
+			replayed: replayed[id],
+			observed: end - start,
+			found:    okStart && okEnd,
+		})
+	}
+
+	return rep
+}
+
+// sameResponse reports whether the target answered as captured: same status
+// and, for errors, the same error code. Successful bodies carry write tokens
+// that differ between instances and are not compared.
+func sameResponse(r result) bool {
+	if r.err != nil || r.status != r.rec.Status {
+		return false
+	}
+
+	return r.status == http.StatusOK || r.code == errorCode([]byte(r.rec.Response))
+}
+
+func printReport(w io.Writer, rep report, sent int, elapsed time.Duration) {
+	fmt.Fprintf(w, "\nreplayed %d requests in %s\n", sent, elapsed.Round(time.Millisecond))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if len(rep.responses) > 0 {
+		fmt.Fprintf(tw, "\n%d responses differ\nline\trequest id\trequest\tcaptured\treplayed\n", len(rep.responses))
+
+		for _, r := range rep.responses {
+			fmt.Fprintf(tw, "%d\t%s\t%s %s\t%s\t%s\n", r.line, r.rec.RequestID, r.rec.Method, r.rec.Path,
+				describe(r.rec.Status, errorCode([]byte(r.rec.Response)), nil), describe(r.status, r.code, r.err))
+		}
+	}
+
+	fmt.Fprintln(tw, "\nuser\tcaptured change\treplayed change\tobserved change\t")
+
+	for _, u := range rep.users {
+		switch {
+		case !u.found:
+			fmt.Fprintf(tw, "%d\t%s\t%s\t-\tunknown to the target\n", u.id, signed(u.captured), signed(u.replayed))
+		case u.differs():
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\tDIFFERS\n", u.id, signed(u.captured), signed(u.replayed), signed(u.observed))
+		default:
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\n", u.id, signed(u.captured), signed(u.replayed), signed(u.observed))
+		}
+	}
+
+	_ = tw.Flush()
+
+	if !rep.differs() {
+		fmt.Fprintln(w, "\nreplay matches the capture")
+	}
+}
+
+func describe(status int, code string, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	if code == "" {
+		return fmt.Sprint(status)
+	}
+
+	return fmt.Sprintf("%d %s", status, code)
+}
+
+func signed(a money.Amount) string {
+	if a >= 0 {
+		return "+" + a.String()
+	}
+
+	return a.String()
+}
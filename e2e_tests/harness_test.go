@@ -0,0 +1,125 @@
+package e2etests
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/api"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/services/balance"
+)
+
+// externalURL points the suite at a running deployment, e.g.
+//
+//	go test ./e2e_tests -args -e2e.base-url=http://localhost:8080
+//
+// Without it every test gets its own in-process API on a fresh database.
+var externalURL = flag.String("e2e.base-url", os.Getenv("E2E_BASE_URL"),
+	"base URL of a running deployment to test instead of an in-process server (env E2E_BASE_URL)")
+
+// seededUsers exist with a zero balance in every in-process deployment,
+// matching the DEV seed of the docker compose stack.
+var seededUsers = []uint64{1, 2, 3}
+
+// startAPI returns the base URL of the API under test: the external
+// deployment if one was given, otherwise the real router served on a random
+// local port over a fresh pgtestutil database, torn down with t.
+func startAPI(t *testing.T) string {
+	t.Helper()
+
+	if *externalURL != "" {
+		waitUntilReady(t, *externalURL)
+
+		return *externalURL
+	}
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	t.Cleanup(cleanup)
+
+	var fixture pgtestutil.Fixture
+	for _, id := range seededUsers {
+		fixture.Users = append(fixture.Users, pgtestutil.User{ID: id})
+	}
+
+	pgtestutil.Seed(t, db, fixture)
+
+	probes := health.New(time.Second, pgutils.PingCheck(db))
+	srv := api.NewServer(0, balance.New(db), probes)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	served := make(chan error, 1)
+
+	go func() { served <- srv.Serve(ln) }()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = srv.Shutdown(ctx)
+
+		if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("serve: %v", err)
+		}
+	})
+
+	probes.MarkStarted()
+
+	return "http://" + ln.Addr().String()
+}
+
+// waitUntilReady waits for an external deployment to pass its readiness
+// probe.
+func waitUntilReady(t *testing.T, baseURL string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), waitReady)
+	defer cancel()
+
+	u := baseURL + "/readyz"
+
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("service not ready at %s within %s (last error: %v)", u, waitReady, describe(resp, err))
+		case <-tick.C:
+		}
+	}
+}
+
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxCapturedBody matches the transaction handler's body limit; anything
+// longer is rejected by the handler anyway.
+const maxCapturedBody = 1 << 20
+
+// captureRequests records each request, its response status and body to rec.
+// A failed write is logged and never affects the response.
+func captureRequests(rec *capture.Writer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody+1))
+			if err != nil {
+				slog.WarnContext(r.Context(), "capture: read request body", "error", err)
+			}
+
+			truncated := len(body) > maxCapturedBody
+			// Hand the handler the same bytes, plus whatever was not read.
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			var resp bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&resp)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if truncated {
+				body = body[:maxCapturedBody]
+			}
+
+			err = rec.Write(capture.Record{
+				Time:          start.UTC(),
+				RequestID:     requestIDFromContext(r.Context()),
+				Method:        r.Method,
+				Path:          r.URL.RequestURI(),
+				Header:        r.Header,
+				Body:          string(body),
+				BodyTruncated: truncated,
+				Status:        status,
+				Response:      resp.String(),
+			})
+			if err != nil {
+				slog.WarnContext(r.Context(), "capture: write record", "error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+)
+
+func TestCaptureRequests(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	probes := health.New(time.Second)
+	probes.MarkStarted()
+
+	router := NewRouter(fakeService{}, probes, WithCapture(capture.NewWriter(&buf)))
+
+	body := `{"state":"win","amount":"10.15","transactionId":"tx-1"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/user/1/transaction", strings.NewReader(body))
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(requestIDHeader, "req-1")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+
+	// Balance reads are not captured.
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user/1/balance", nil))
+
+	recs, err := capture.Read(&buf)
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+
+	if len(recs) != 1 {
+		t.Fatalf("captured %d records, want 1", len(recs))
+	}
+
+	got := recs[0]
+
+	if got.Body != body {
+		t.Errorf("body = %q, want %q", got.Body, body)
+	}
+
+	if got.Status != http.StatusOK || got.Response != rec.Body.String() {
+		t.Errorf("response = %d %q, want 200 %q", got.Status, got.Response, rec.Body)
+	}
+
+	if got.Path != "/user/1/transaction" || got.RequestID != "req-1" {
+		t.Errorf("path, requestId = %q, %q", got.Path, got.RequestID)
+	}
+
+	if got.Header.Get("Authorization") != capture.Redacted || got.Header.Get("Source-Type") != "game" {
+		t.Errorf("header = %v", got.Header)
+	}
+}
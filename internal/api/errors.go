@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+)
+
+// errorStatus is the single mapping from domain error codes to HTTP status.
+// Codes missing here are answered with 500.
+var errorStatus = map[apperr.Code]int{
+	apperr.CodeInvalidRequest:       http.StatusBadRequest,
+	apperr.CodeInvalidUserID:        http.StatusBadRequest,
+	apperr.CodeInvalidSourceType:    http.StatusBadRequest,
+	apperr.CodeInvalidState:         http.StatusBadRequest,
+	apperr.CodeInvalidAmount:        http.StatusBadRequest,
+	apperr.CodeUserNotFound:         http.StatusNotFound,
+	apperr.CodeDuplicateTransaction: http.StatusConflict,
+	apperr.CodeInsufficientFunds:    http.StatusConflict,
+	apperr.CodeTimeout:              http.StatusServiceUnavailable,
+}
+
+// retryAfterSeconds is sent with 503 answers; transactions are idempotent by
+// transactionId, so providers can safely resend the same request.
+const retryAfterSeconds = "1"
+
+type errorBody struct {
+	Code      apperr.Code         `json:"code"`
+	Message   string              `json:"message"`
+	Details   []apperr.FieldError `json:"details,omitempty"`
+	RequestID string              `json:"requestId,omitempty"`
+}
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+// writeError maps err through errorStatus and writes the error envelope:
+//
+//	{"error":{"code":"INSUFFICIENT_FUNDS","message":"insufficient funds","requestId":"..."}}
+//
+// Errors without a domain code are logged and reported as INTERNAL without
+// leaking their message.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	body := errorBody{
+		Code:      apperr.CodeInternal,
+		Message:   "internal error",
+		RequestID: requestIDFromContext(r.Context()),
+	}
+
+	var de *apperr.Error
+
+	status, ok := 0, false
+	if errors.As(err, &de) {
+		status, ok = errorStatus[de.Code]
+	}
+
+	if !ok {
+		slog.ErrorContext(r.Context(), "request failed", "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorEnvelope{Error: body})
+
+		return
+	}
+
+	body.Code = de.Code
+	body.Message = de.Message
+	body.Details = de.Fields
+
+	if status == http.StatusServiceUnavailable {
+		slog.WarnContext(r.Context(), "request timed out", "error", err)
+		w.Header().Set("Retry-After", retryAfterSeconds)
+	}
+
+	writeJSON(w, status, errorEnvelope{Error: body})
+}
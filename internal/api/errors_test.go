@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		svcErr     error
+		source     string
+		body       string
+		wantStatus int
+		wantCode   apperr.Code
+		wantField  string
+	}{
+		{
+			name:       "duplicate",
+			svcErr:     fmt.Errorf("process transaction: %w", apperr.ErrDuplicateTransaction),
+			wantStatus: http.StatusConflict,
+			wantCode:   apperr.CodeDuplicateTransaction,
+		},
+		{
+			name:       "insufficient_funds",
+			svcErr:     fmt.Errorf("pre-check decrease: %w", apperr.ErrInsufficientFunds),
+			wantStatus: http.StatusConflict,
+			wantCode:   apperr.CodeInsufficientFunds,
+		},
+		{
+			name:       "user_not_found",
+			svcErr:     apperr.ErrUserNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   apperr.CodeUserNotFound,
+		},
+		{
+			name:       "timeout_is_retryable",
+			svcErr:     fmt.Errorf("process transaction: %w: %w", apperr.ErrTimeout, errors.New("lock timeout")),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   apperr.CodeTimeout,
+		},
+		{
+			name:       "unexpected_error_is_internal",
+			svcErr:     errors.New("connection reset by peer"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperr.CodeInternal,
+		},
+		{
+			name:       "invalid_amount_has_field_details",
+			body:       `{"state":"win","amount":"1.234","transactionId":"tx"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperr.CodeInvalidAmount,
+			wantField:  "amount",
+		},
+		{
+			name:       "invalid_state",
+			body:       `{"state":"draw","amount":"1.00","transactionId":"tx"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperr.CodeInvalidState,
+			wantField:  "state",
+		},
+		{
+			name:       "invalid_source",
+			source:     "casino",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperr.CodeInvalidSourceType,
+			wantField:  "Source-Type",
+		},
+		{
+			name:       "invalid_json",
+			body:       `{"state":`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperr.CodeInvalidRequest,
+			wantField:  "body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			probes := health.New(time.Second)
+			router := NewRouter(fakeService{err: tt.svcErr}, probes)
+
+			body := tt.body
+			if body == "" {
+				body = `{"state":"win","amount":"1.00","transactionId":"tx"}`
+			}
+
+			source := tt.source
+			if source == "" {
+				source = "game"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/user/1/transaction", strings.NewReader(body))
+			req.Header.Set("Source-Type", source)
+			req.Header.Set(requestIDHeader, "req-"+tt.name)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status: want %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			var env errorEnvelope
+
+			err := json.NewDecoder(rec.Body).Decode(&env)
+			if err != nil {
+				t.Fatalf("decode envelope: %v", err)
+			}
+
+			if env.Error.Code != tt.wantCode {
+				t.Fatalf("code: want %s, got %s", tt.wantCode, env.Error.Code)
+			}
+
+			if env.Error.RequestID != "req-"+tt.name {
+				t.Fatalf("requestId: want %q, got %q", "req-"+tt.name, env.Error.RequestID)
+			}
+
+			if tt.wantField != "" && (len(env.Error.Details) == 0 || env.Error.Details[0].Field != tt.wantField) {
+				t.Fatalf("details: want field %q, got %+v", tt.wantField, env.Error.Details)
+			}
+
+			if tt.wantStatus == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+				t.Fatal("503 without Retry-After header")
+			}
+
+			if tt.wantCode == apperr.CodeInternal && env.Error.Message != "internal error" {
+				t.Fatalf("internal error message leaked: %q", env.Error.Message)
+			}
+		})
+	}
+}
@@ -3,19 +3,23 @@ package api
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 
-	"github.com/fastprodman/EntainHW/internal/repos/transactions"
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/logging"
 	"github.com/fastprodman/EntainHW/internal/services/balance"
+	"github.com/fastprodman/EntainHW/pkg/money"
 	"github.com/go-chi/chi/v5"
 )
 
+// writeTokenHeader carries the balance.WriteToken returned by a transaction
+// POST; sending it back on a balance GET guarantees read-your-writes.
+const writeTokenHeader = "X-Write-Token"
+
 // HandlerProvider wraps a BalanceService and exposes HTTP handlers.
 type HandlerProvider struct {
 	svc balance.BalanceService
@@ -38,14 +42,11 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 		slog.Error("failed to encode JSON response", "error", err)
 
 		// As best-effort, write a minimal error payload if headers not sent
-		http.Error(w, `{"error":"internal json encode failure"}`, http.StatusInternalServerError)
+		http.Error(w, `{"error":{"code":"INTERNAL","message":"internal json encode failure"}}`,
+			http.StatusInternalServerError)
 	}
 }
 
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
-}
-
 // parseUserIDFromPath reads `{userId}` from chi routes like:
 //
 //	GET  /user/{userId}/balance
@@ -53,15 +54,15 @@ func writeError(w http.ResponseWriter, status int, msg string) {
 func parseUserIDFromPath(r *http.Request) (uint64, error) {
 	idStr := chi.URLParam(r, "userId")
 	if idStr == "" {
-		return 0, fmt.Errorf("missing userId")
+		return 0, apperr.Invalid(apperr.CodeInvalidUserID, "userId", "required")
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("invalid userId: %w", err)
+		return 0, apperr.Invalid(apperr.CodeInvalidUserID, "userId", "must be an unsigned integer")
 	}
 	if id == 0 {
-		return 0, fmt.Errorf("invalid userId: must be positive")
+		return 0, apperr.Invalid(apperr.CodeInvalidUserID, "userId", "must be positive")
 	}
 
 	return id, nil
@@ -77,7 +78,7 @@ func parseSourceType(h http.Header) (balance.SourceType, error) {
 	case "payment":
 		return balance.SourcePayment, nil
 	default:
-		return "", fmt.Errorf("invalid Source-Type")
+		return "", apperr.Invalid(apperr.CodeInvalidSourceType, "Source-Type", "must be game, server or payment")
 	}
 }
 
@@ -94,52 +95,43 @@ func parseTxState(s string) (balance.TxState, error) {
 	case "lose":
 		return balance.TxLose, nil
 	default:
-		return "", fmt.Errorf("invalid state")
+		return "", apperr.Invalid(apperr.CodeInvalidState, "state", "must be win or lose")
 	}
 }
 
-// parseAmountCents converts a decimal string with up to 2 fractional digits into cents.
-func parseAmountCents(s string) (int64, error) {
-	s = strings.TrimSpace(s)
+func invalidAmount(issue string) error {
+	return apperr.Invalid(apperr.CodeInvalidAmount, "amount", issue)
+}
+
+// amountOptions is the accepted amount syntax: unsigned, up to 2 decimals.
+var amountOptions = money.ParseOptions{Scale: money.DefaultScale}
+
+// parseAmount converts a decimal string with up to 2 fractional digits into
+// a positive money.Amount.
+func parseAmount(s string) (money.Amount, error) {
 	if s == "" {
-		return 0, fmt.Errorf("amount required")
-	}
-	neg := false
-	if s[0] == '+' {
-		s = s[1:]
-	}
-	if s[0] == '-' {
-		neg = true
-		s = s[1:]
-	}
-	parts := strings.Split(s, ".")
-	if len(parts) > 2 {
-		return 0, fmt.Errorf("invalid amount")
-	}
-	intPart := parts[0]
-	frac := "00"
-	if len(parts) == 2 {
-		if len(parts[1]) > 2 {
-			return 0, fmt.Errorf("amount supports up to 2 decimals")
-		}
-		frac = parts[1] + strings.Repeat("0", 2-len(parts[1]))
-	}
-	ip, err := strconv.ParseInt(intPart, 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid amount integer")
+		return 0, invalidAmount("required")
 	}
-	fp, err := strconv.ParseInt(frac, 10, 64)
+
+	amount, err := money.ParseWith(s, amountOptions)
 	if err != nil {
-		return 0, fmt.Errorf("invalid amount fractional")
-	}
-	total := ip*100 + fp
-	if neg {
-		total = -total
+		switch {
+		case errors.Is(err, money.ErrPrecision):
+			return 0, invalidAmount("supports up to 2 decimals")
+		case errors.Is(err, money.ErrOverflow):
+			return 0, invalidAmount("too large")
+		case errors.Is(err, money.ErrSign):
+			return 0, invalidAmount("must not be signed")
+		default:
+			return 0, invalidAmount("must be a decimal number like 10.15")
+		}
 	}
-	if total <= 0 {
-		return 0, fmt.Errorf("amount must be > 0")
+
+	if amount <= 0 {
+		return 0, invalidAmount("must be > 0")
 	}
-	return total, nil
+
+	return amount, nil
 }
 
 // --- Handlers ---
@@ -148,26 +140,28 @@ func parseAmountCents(s string) (int64, error) {
 func (h *HandlerProvider) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := parseUserIDFromPath(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid userId in path")
+		writeError(w, r, err)
 		return
 	}
 
-	bal, err := h.svc.GetBalance(r.Context(), userID)
+	logging.AddAttrs(r.Context(), slog.Uint64("userId", userID))
+
+	after, err := balance.ParseWriteToken(r.Header.Get(writeTokenHeader))
 	if err != nil {
-		// domain mapping
-		if errors.Is(err, users.ErrUserNotFound) {
-			writeError(w, http.StatusNotFound, "user not found")
-			return
-		}
+		writeError(w, r, apperr.Invalid(apperr.CodeInvalidRequest, writeTokenHeader, "malformed write token"))
+		return
+	}
 
-		writeError(w, http.StatusInternalServerError, "internal error")
+	bal, err := h.svc.GetBalance(r.Context(), userID, after)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	// spec: response has userId (uint64) and balance as string with 2 decimals
 	resp := map[string]any{
 		"userId":  userID,
-		"balance": fmt.Sprintf("%.2f", float64(bal)/100.0),
+		"balance": bal,
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -176,16 +170,20 @@ func (h *HandlerProvider) GetBalanceHandler(w http.ResponseWriter, r *http.Reque
 func (h *HandlerProvider) ProcessTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := parseUserIDFromPath(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid userId in path")
+		writeError(w, r, err)
 		return
 	}
 
+	logging.AddAttrs(r.Context(), slog.Uint64("userId", userID))
+
 	source, err := parseSourceType(r.Header)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid Source-Type header")
+		writeError(w, r, err)
 		return
 	}
 
+	logging.AddAttrs(r.Context(), slog.String("source", string(source)))
+
 	// Limit body size; disallow unknown fields
 	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1MB cap
 	defer r.Body.Close()
@@ -196,53 +194,47 @@ func (h *HandlerProvider) ProcessTransactionHandler(w http.ResponseWriter, r *ht
 	err = dec.Decode(&req)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
-			writeError(w, http.StatusBadRequest, "empty body")
+			writeError(w, r, apperr.Invalid(apperr.CodeInvalidRequest, "body", "empty body"))
 			return
 		}
 
-		writeError(w, http.StatusBadRequest, "invalid JSON")
+		writeError(w, r, apperr.Invalid(apperr.CodeInvalidRequest, "body", "invalid JSON"))
 		return
 	}
 
 	state, err := parseTxState(req.State)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid state")
+		writeError(w, r, err)
 		return
 	}
-	amountCents, err := parseAmountCents(req.Amount)
+	amount, err := parseAmount(req.Amount)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, err)
 		return
 	}
 	if req.TransactionID == "" {
-		writeError(w, http.StatusBadRequest, "transactionId required")
+		writeError(w, r, apperr.Invalid(apperr.CodeInvalidRequest, "transactionId", "required"))
 		return
 	}
 
+	logging.AddAttrs(r.Context(), slog.String("transactionId", req.TransactionID))
+
 	tx := balance.Transaction{
 		TransactionID: req.TransactionID,
 		UserID:        userID,
 		Source:        source,
 		State:         state,
-		AmountMinor:   amountCents,
+		Amount:        amount,
 	}
 
-	err = h.svc.ProcessTransaction(r.Context(), tx)
+	token, err := h.svc.ProcessTransaction(r.Context(), tx)
 	if err != nil {
-		switch {
-		case errors.Is(err, transactions.ErrDuplicateTransaction):
-			writeError(w, http.StatusConflict, "duplicate transaction")
-			return
-		case errors.Is(err, users.ErrInsufficientFunds):
-			writeError(w, http.StatusConflict, "insufficient funds")
-			return
-		case errors.Is(err, users.ErrUserNotFound):
-			writeError(w, http.StatusNotFound, "user not found")
-			return
-		default:
-			writeError(w, http.StatusInternalServerError, "internal error")
-			return
-		}
+		writeError(w, r, err)
+		return
+	}
+
+	if token != "" {
+		w.Header().Set(writeTokenHeader, string(token))
 	}
 
 	// spec says 200 OK on success (payload is up to you)
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    money.Amount
+		wantErr bool
+	}{
+		{"10.15", 1015, false},
+		{"10.1", 1010, false},
+		{"10", 1000, false},
+		{"0.01", 1, false},
+		{"", 0, true},
+		{".", 0, true},
+		{"5.", 0, true},
+		{".5", 0, true},
+		{"1e3", 0, true},
+		{" 1.00", 0, true},
+		{"+1.00", 0, true},
+		{"-1.00", 0, true},
+		{"0", 0, true},
+		{"0.00", 0, true},
+		{"1.234", 0, true},
+		{"92233720368547758.08", 0, true}, // would overflow int64 cents
+		{"999999999999999999999", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, apperr.ErrInvalidAmount) {
+				t.Fatalf("parseAmount(%q): want ErrInvalidAmount, got %v (%d)", tt.in, err, got)
+			}
+
+			continue
+		}
+
+		if err != nil || got != tt.want {
+			t.Fatalf("parseAmount(%q): want %d, got %d (%v)", tt.in, tt.want, got, err)
+		}
+	}
+}
+
+func TestWriteTokenHeader(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/user/1/transaction",
+		strings.NewReader(`{"state":"win","amount":"1.00","transactionId":"tx"}`))
+	req.Header.Set("Source-Type", "game")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	token := rec.Header().Get(writeTokenHeader)
+	if rec.Code != http.StatusOK || token == "" {
+		t.Fatalf("POST: want 200 with %s, got %d %q", writeTokenHeader, rec.Code, token)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "no_token", token: "", wantStatus: http.StatusOK},
+		{name: "token_from_post", token: token, wantStatus: http.StatusOK},
+		{name: "malformed_token", token: "not-an-lsn", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/user/1/balance", nil)
+			if tt.token != "" {
+				req.Header.Set(writeTokenHeader, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET: want %d, got %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+)
+
+// healthHandlers exposes health.Probes as Kubernetes-style probe endpoints.
+type healthHandlers struct {
+	probes *health.Probes
+}
+
+func writeReport(w http.ResponseWriter, rep health.Report) {
+	status := http.StatusOK
+	if !rep.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, rep)
+}
+
+// Livez handles GET /livez (and the legacy /healthz).
+func (h healthHandlers) Livez(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, h.probes.Live())
+}
+
+// Startupz handles GET /startupz.
+func (h healthHandlers) Startupz(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, h.probes.Startup())
+}
+
+// Readyz handles GET /readyz.
+func (h healthHandlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, h.probes.Ready(r.Context()))
+}
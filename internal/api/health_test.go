@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	t.Parallel()
+
+	dbUp := true
+	probes := health.New(time.Second, health.Check{
+		Name: "db",
+		Fn: func(context.Context) error {
+			if !dbUp {
+				return errors.New("db down")
+			}
+
+			return nil
+		},
+	})
+	router := NewRouter(fakeService{}, probes)
+
+	get := func(path string) (int, health.Report) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var rep health.Report
+
+		err := json.NewDecoder(rec.Body).Decode(&rep)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+
+		return rec.Code, rep
+	}
+
+	steps := []struct {
+		name   string
+		before func()
+		path   string
+		want   int
+	}{
+		{"live_before_start", nil, "/livez", http.StatusOK},
+		{"startup_before_start", nil, "/startupz", http.StatusServiceUnavailable},
+		{"ready_before_start", nil, "/readyz", http.StatusServiceUnavailable},
+		{"startup_after_start", probes.MarkStarted, "/startupz", http.StatusOK},
+		{"ready_after_start", nil, "/readyz", http.StatusOK},
+		{"ready_db_down", func() { dbUp = false }, "/readyz", http.StatusServiceUnavailable},
+		{"ready_db_back", func() { dbUp = true }, "/readyz", http.StatusOK},
+		{"ready_draining", probes.MarkDraining, "/readyz", http.StatusServiceUnavailable},
+		{"live_draining", nil, "/livez", http.StatusOK},
+		{"legacy_healthz", nil, "/healthz", http.StatusOK},
+	}
+
+	for _, st := range steps {
+		if st.before != nil {
+			st.before()
+		}
+
+		code, rep := get(st.path)
+		if code != st.want {
+			t.Fatalf("%s: want %d, got %d (%+v)", st.name, st.want, code, rep)
+		}
+
+		if st.name == "ready_db_down" && rep.Checks["db"].Error != "db down" {
+			t.Fatalf("%s: missing per-check breakdown: %+v", st.name, rep)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/services/balance"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// fakeService records a child span for every call so tests can assert
+// nesting, and fails every call with err when set.
+type fakeService struct {
+	err error
+}
+
+func (f fakeService) GetBalance(ctx context.Context, _ uint64, _ balance.WriteToken) (money.Amount, error) {
+	_, span := tracing.Start(ctx, "test", "fake.GetBalance")
+	defer span.End()
+
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	return 925, nil
+}
+
+func (f fakeService) ProcessTransaction(ctx context.Context, _ balance.Transaction) (balance.WriteToken, error) {
+	_, span := tracing.Start(ctx, "test", "fake.ProcessTransaction")
+	defer span.End()
+
+	if f.err != nil {
+		return "", f.err
+	}
+
+	return "0/16B3748", nil
+}
+
+func newTestRouter() http.Handler {
+	probes := health.New(time.Second)
+	probes.MarkStarted()
+
+	return NewRouter(fakeService{}, probes)
+}
+
+func setupInMemoryTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	prevTP := otel.GetTracerProvider()
+	prevProp := otel.GetTextMapPropagator()
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	return exp
+}
+
+//nolint:paralleltest
+func TestTraceRequests_SpanTreeAndPropagation(t *testing.T) {
+	exp := setupInMemoryTracing(t)
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	body := `{"state":"win","amount":"1.00","transactionId":"tx-1"}`
+	req := httptest.NewRequest(http.MethodPost, "/user/7/transaction", strings.NewReader(body))
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set("Traceparent", "00-"+traceID+"-"+parentID+"-01")
+
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: want 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+
+	// The syncer exports on End, so the child comes first.
+	child, server := spans[0], spans[1]
+
+	if server.Name != "POST /user/{userId}/transaction" {
+		t.Fatalf("server span name: got %q", server.Name)
+	}
+
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Fatalf("server span did not continue provider trace: got %s", got)
+	}
+
+	if got := server.Parent.SpanID().String(); got != parentID {
+		t.Fatalf("server span parent: want %s, got %s", parentID, got)
+	}
+
+	if child.Name != "fake.ProcessTransaction" {
+		t.Fatalf("child span name: got %q", child.Name)
+	}
+
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("service span is not a child of the server span")
+	}
+
+	var gotRoute, gotStatus bool
+
+	for _, kv := range server.Attributes {
+		switch {
+		case kv == semconv.HTTPRoute("/user/{userId}/transaction"):
+			gotRoute = true
+		case kv == semconv.HTTPResponseStatusCode(http.StatusOK):
+			gotStatus = true
+		}
+	}
+
+	if !gotRoute || !gotStatus {
+		t.Fatalf("missing route/status attributes: %v", server.Attributes)
+	}
+}
+
+//nolint:paralleltest
+func TestTraceRequests_NewRootWithoutTraceparent(t *testing.T) {
+	exp := setupInMemoryTracing(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/7/balance", nil)
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, req)
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+
+	server := spans[1]
+	if server.Parent.IsValid() {
+		t.Fatalf("expected a root span, got parent %s", server.Parent.SpanID())
+	}
+
+	if server.Name != "GET /user/{userId}/balance" {
+		t.Fatalf("server span name: got %q", server.Name)
+	}
+}
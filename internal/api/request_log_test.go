@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/infra/logging"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	prev := slog.Default()
+	slog.SetDefault(slog.New(logging.NewContextHandler(slog.NewJSONHandler(&buf, nil))))
+
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	return &buf
+}
+
+func accessLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+
+		err := json.Unmarshal([]byte(line), &m)
+		if err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+
+		if m["msg"] == "http request" {
+			return m
+		}
+	}
+
+	t.Fatalf("no access log line in %q", buf.String())
+
+	return nil
+}
+
+//nolint:paralleltest // swaps the default logger
+func TestRequestID_PropagatesAndAccessLog(t *testing.T) {
+	buf := captureLogs(t)
+
+	body := `{"state":"win","amount":"1.00","transactionId":"tx-42"}`
+	req := httptest.NewRequest(http.MethodPost, "/user/7/transaction", strings.NewReader(body))
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set(requestIDHeader, "provider-req-1")
+
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(requestIDHeader); got != "provider-req-1" {
+		t.Fatalf("X-Request-ID not echoed: got %q", got)
+	}
+
+	line := accessLine(t, buf)
+
+	want := map[string]any{
+		"requestId":     "provider-req-1",
+		"method":        http.MethodPost,
+		"route":         "/user/{userId}/transaction",
+		"status":        float64(http.StatusOK),
+		"userId":        float64(7),
+		"transactionId": "tx-42",
+		"source":        "game",
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Fatalf("access log %q: want %v, got %v", k, v, line[k])
+		}
+	}
+
+	if _, ok := line["duration"]; !ok {
+		t.Fatalf("access log missing duration: %v", line)
+	}
+}
+
+//nolint:paralleltest // swaps the default logger
+func TestRequestID_GeneratedWhenMissingOrInvalid(t *testing.T) {
+	_ = captureLogs(t)
+
+	for _, in := range []string{"", "has space", strings.Repeat("x", maxRequestIDLen+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/user/7/balance", nil)
+		if in != "" {
+			req.Header.Set(requestIDHeader, in)
+		}
+
+		rec := httptest.NewRecorder()
+		newTestRouter().ServeHTTP(rec, req)
+
+		got := rec.Header().Get(requestIDHeader)
+		if got == "" || got == in {
+			t.Fatalf("input %q: expected a generated request id, got %q", in, got)
+		}
+	}
+}
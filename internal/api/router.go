@@ -3,25 +3,52 @@ package api
 import (
 	"net/http"
 
+	"github.com/fastprodman/EntainHW/internal/infra/capture"
+	"github.com/fastprodman/EntainHW/internal/infra/health"
 	"github.com/fastprodman/EntainHW/internal/services/balance"
 	"github.com/go-chi/chi/v5"
 )
 
+// Option configures optional router behaviour.
+type Option func(*routerOptions)
+
+type routerOptions struct {
+	capture *capture.Writer
+}
+
+// WithCapture records every transaction request and its response to w.
+func WithCapture(w *capture.Writer) Option {
+	return func(o *routerOptions) { o.capture = w }
+}
+
 // NewRouter constructs an http.ServeMux with all API endpoints registered.
-func NewRouter(svc balance.BalanceService) http.Handler {
+func NewRouter(svc balance.BalanceService, probes *health.Probes, opts ...Option) http.Handler {
+	var o routerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	h := NewHandler(svc)
+	hh := healthHandlers{probes: probes}
 	r := chi.NewRouter()
 
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"ok"}`))
-	})
+	r.Use(traceRequests, assignRequestID, logAccess)
+
+	r.Get("/livez", hh.Livez)
+	r.Get("/readyz", hh.Readyz)
+	r.Get("/startupz", hh.Startupz)
+	r.Get("/healthz", hh.Livez)
 
 	// These call your existing handlers; they still read from r.URL.Path,
 	// which will be /user/{id}/balance etc. You could also refactor them
 	// to read chi.URLParam(r, "userId") if you prefer.
 	r.Get("/user/{userId}/balance", h.GetBalanceHandler)
-	r.Post("/user/{userId}/transaction", h.ProcessTransactionHandler)
+	txRoute := r.With()
+	if o.capture != nil {
+		txRoute = r.With(captureRequests(o.capture))
+	}
+
+	txRoute.Post("/user/{userId}/transaction", h.ProcessTransactionHandler)
 
 	return r
 }
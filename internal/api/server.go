@@ -5,12 +5,13 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/fastprodman/EntainHW/internal/infra/health"
 	"github.com/fastprodman/EntainHW/internal/services/balance"
 )
 
 // NewServer creates and returns a configured *http.Server for the balance API.
-func NewServer(port uint16, svc balance.BalanceService) *http.Server {
-	mux := NewRouter(svc)
+func NewServer(port uint16, svc balance.BalanceService, probes *health.Probes, opts ...Option) *http.Server {
+	mux := NewRouter(svc, probes, opts...)
 
 	addr := fmt.Sprintf(":%d", port)
 
@@ -0,0 +1,119 @@
+// Package apperr defines the domain errors shared by the service, the
+// repositories and the HTTP layer. Every error carries a stable,
+// machine-readable Code that providers can rely on instead of matching
+// messages.
+package apperr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Code is a stable, machine-readable error identifier.
+type Code string
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field string `json:"field"`
+	Issue string `json:"issue"`
+}
+
+// Error is a domain error with a stable code and optional field details.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+}
+
+const (
+	CodeInvalidRequest       Code = "INVALID_REQUEST"
+	CodeInvalidUserID        Code = "INVALID_USER_ID"
+	CodeInvalidSourceType    Code = "INVALID_SOURCE_TYPE"
+	CodeInvalidState         Code = "INVALID_STATE"
+	CodeInvalidAmount        Code = "INVALID_AMOUNT"
+	CodeUserNotFound         Code = "USER_NOT_FOUND"
+	CodeDuplicateTransaction Code = "DUPLICATE_TRANSACTION"
+	CodeInsufficientFunds    Code = "INSUFFICIENT_FUNDS"
+	CodeTimeout              Code = "TIMEOUT"
+	CodeInternal             Code = "INTERNAL"
+)
+
+// Domain sentinels. Compare with errors.Is; any *Error with the same Code
+// matches, so validation errors carrying field details match too.
+var (
+	ErrInvalidRequest       = New(CodeInvalidRequest, "invalid request")
+	ErrInvalidUserID        = New(CodeInvalidUserID, "invalid userId")
+	ErrInvalidSourceType    = New(CodeInvalidSourceType, "invalid Source-Type header")
+	ErrInvalidState         = New(CodeInvalidState, "invalid state")
+	ErrInvalidAmount        = New(CodeInvalidAmount, "invalid amount")
+	ErrUserNotFound         = New(CodeUserNotFound, "user not found")
+	ErrDuplicateTransaction = New(CodeDuplicateTransaction, "duplicate transaction")
+	ErrInsufficientFunds    = New(CodeInsufficientFunds, "insufficient funds")
+	ErrTimeout              = New(CodeTimeout, "timed out waiting for the database, retry later")
+)
+
+// sentinels gives Invalid the base message for a code.
+var sentinels = map[Code]*Error{
+	CodeInvalidRequest:       ErrInvalidRequest,
+	CodeInvalidUserID:        ErrInvalidUserID,
+	CodeInvalidSourceType:    ErrInvalidSourceType,
+	CodeInvalidState:         ErrInvalidState,
+	CodeInvalidAmount:        ErrInvalidAmount,
+	CodeUserNotFound:         ErrUserNotFound,
+	CodeDuplicateTransaction: ErrDuplicateTransaction,
+	CodeInsufficientFunds:    ErrInsufficientFunds,
+	CodeTimeout:              ErrTimeout,
+}
+
+// New returns an *Error with the given code and message.
+func New(code Code, msg string) *Error {
+	return &Error{Code: code, Message: msg}
+}
+
+// Invalid returns a validation error for a single request field.
+func Invalid(code Code, field, issue string) *Error {
+	msg := "invalid request"
+	if base, ok := sentinels[code]; ok {
+		msg = base.Message
+	}
+
+	return &Error{
+		Code:    code,
+		Message: msg,
+		Fields:  []FieldError{{Field: field, Issue: issue}},
+	}
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	issues := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		issues = append(issues, f.Field+": "+f.Issue)
+	}
+
+	return e.Message + " (" + strings.Join(issues, "; ") + ")"
+}
+
+// Is reports whether target is an *Error with the same Code.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+
+	return e.Code == t.Code
+}
+
+// CodeOf returns the Code of the first *Error in err's chain, or
+// CodeInternal if there is none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return CodeInternal
+}
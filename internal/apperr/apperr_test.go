@@ -0,0 +1,59 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError_IsMatchesByCode(t *testing.T) {
+	t.Parallel()
+
+	withDetails := Invalid(CodeInvalidAmount, "amount", "must be > 0")
+	wrapped := fmt.Errorf("handler: %w", withDetails)
+
+	if !errors.Is(wrapped, ErrInvalidAmount) {
+		t.Fatalf("validation error must match its sentinel")
+	}
+
+	if errors.Is(wrapped, ErrInvalidState) {
+		t.Fatalf("different codes must not match")
+	}
+
+	if errors.Is(errors.New("invalid amount"), ErrInvalidAmount) {
+		t.Fatalf("plain errors must not match by message")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want Code
+	}{
+		{fmt.Errorf("x: %w", ErrDuplicateTransaction), CodeDuplicateTransaction},
+		{ErrInsufficientFunds, CodeInsufficientFunds},
+		{errors.New("boom"), CodeInternal},
+		{nil, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Fatalf("CodeOf(%v): want %s, got %s", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestInvalid_Message(t *testing.T) {
+	t.Parallel()
+
+	err := Invalid(CodeInvalidAmount, "amount", "must be > 0")
+	if err.Message != ErrInvalidAmount.Message {
+		t.Fatalf("message: want %q, got %q", ErrInvalidAmount.Message, err.Message)
+	}
+
+	if got := err.Error(); got != "invalid amount (amount: must be > 0)" {
+		t.Fatalf("Error(): got %q", got)
+	}
+}
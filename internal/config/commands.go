@@ -0,0 +1,43 @@
+package config
+
+import (
+	"log/slog"
+	"time"
+)
+
+// APIConfig is the configuration of cmd/api.
+//
+//nolint:lll
+type APIConfig struct {
+	Port            uint16        `env:"API_PORT" default:"8080" validate:"min=1" desc:"HTTP listen port."`
+	ShutdownTimeout time.Duration `env:"API_SHUTDOWN_TIMEOUT" default:"5s" validate:"min=0s" desc:"Time allowed for in-flight requests on shutdown."`
+	DrainDelay      time.Duration `env:"API_SHUTDOWN_DRAIN_DELAY" default:"0s" validate:"min=0s" desc:"Time between failing /readyz and stopping the server on shutdown."`
+	ReadyTimeout    time.Duration `env:"API_READINESS_TIMEOUT" default:"1s" validate:"min=1ms" desc:"Deadline of the /readyz dependency checks."`
+	LogLevel        slog.Level    `env:"APP_LOG_LEVEL" default:"INFO" reload:"true" desc:"Log level: DEBUG, INFO, WARN or ERROR."`
+	Storage         string        `env:"STORAGE" default:"postgres" validate:"oneof=postgres memory" desc:"Storage: postgres, or memory (seeds users 1-3, state lost on exit)."`
+	CaptureFile     string        `env:"CAPTURE_FILE" required:"false" desc:"Append transaction requests and responses to this JSONL file for cmd/replay; empty disables."`
+	Postgres        *PostgresConfig
+	Tracing         *TracingConfig
+	BalanceCache    *BalanceCacheConfig
+}
+
+// MaintenanceConfig is the configuration of cmd/maintenance.
+//
+//nolint:lll
+type MaintenanceConfig struct {
+	DSN      string     `env:"PG_DSN" validate:"nonempty" secret:"true" desc:"Postgres connection string."`
+	LogLevel slog.Level `env:"APP_LOG_LEVEL" default:"INFO" desc:"Log level: DEBUG, INFO, WARN or ERROR."`
+	// Interval schedules a pass every Interval; 0 runs a single pass and
+	// exits, for use from cron.
+	Interval   time.Duration `env:"MAINTENANCE_INTERVAL" default:"0s" validate:"min=0s" desc:"Run a maintenance pass every interval; 0 runs once and exits."`
+	Partitions *PartitionConfig
+}
+
+// MigratorConfig is the configuration of cmd/migrator.
+//
+//nolint:lll
+type MigratorConfig struct {
+	DSN      string     `env:"PG_DSN" validate:"nonempty" secret:"true" desc:"Postgres connection string."`
+	LogLevel slog.Level `env:"APP_LOG_LEVEL" default:"INFO" desc:"Log level: DEBUG, INFO, WARN or ERROR."`
+	AppEnv   string     `env:"APP_ENV" required:"false" desc:"DEV seeds users 1-3 after migrating."`
+}
@@ -5,14 +5,84 @@ import (
 	"time"
 )
 
+// Fields carry a `desc` tag, listed by envconf.Describe; regenerate
+// .env.example and docs/configuration.md with `go run ./cmd/configdoc` after
+// changing them.
+
+//nolint:lll
 type PostgresConfig struct {
-	DSN             string        `env:"PG_DSN"`
-	MaxOpenConns    int           `env:"PG_MAX_OPEN_CONNS"`
-	MaxIdleConns    int           `env:"PG_MAX_IDLE_CONNS"`
-	ConnMaxIdleTime time.Duration `env:"PG_CONN_MAX_IDLE_TIME"`
-	ConnMaxLifetime time.Duration `env:"PG_CONN_MAX_LIFETIME"`
+	// Driver selects the backend: DriverStdlib or DriverPgxPool.
+	Driver string `env:"PG_DRIVER" default:"stdlib" validate:"oneof=stdlib pgxpool" desc:"Postgres backend: stdlib (database/sql over pgx) or pgxpool (native pool)."`
+	// DSN is checked by cmd/api only with STORAGE=postgres, so memory mode
+	// starts without one.
+	DSN string `env:"PG_DSN" required:"false" secret:"true" desc:"Postgres connection string; required with STORAGE=postgres."`
+	// ReplicaDSN optionally points balance reads at a read replica.
+	ReplicaDSN string `env:"PG_REPLICA_DSN" required:"false" secret:"true" desc:"Read replica for balance reads; empty reads from the primary."`
+
+	MaxOpenConns    int           `env:"PG_MAX_OPEN_CONNS" default:"25" validate:"min=1" reload:"true" desc:"Maximum open connections."`
+	MaxIdleConns    int           `env:"PG_MAX_IDLE_CONNS" default:"25" validate:"min=0" reload:"true" desc:"Maximum idle connections (stdlib only)."`
+	ConnMaxIdleTime time.Duration `env:"PG_CONN_MAX_IDLE_TIME" default:"5m" validate:"min=0s" reload:"true" desc:"Close connections idle this long."`
+	ConnMaxLifetime time.Duration `env:"PG_CONN_MAX_LIFETIME" default:"1h" validate:"min=0s" reload:"true" desc:"Close connections open this long."`
+
+	// pgxpool only; MaxIdleConns has no pgxpool counterpart.
+	MinConns               int           `env:"PG_MIN_CONNS" default:"2" validate:"min=0" desc:"Connections kept open (pgxpool only)."`
+	HealthCheckPeriod      time.Duration `env:"PG_HEALTH_CHECK_PERIOD" default:"1m" validate:"min=0s" desc:"Idle connection health check period (pgxpool only)."`
+	StatementCacheCapacity int           `env:"PG_STATEMENT_CACHE_CAPACITY" default:"512" validate:"min=0" desc:"Prepared statements cached per connection (pgxpool only)."`
+
+	// Client-side deadlines for a single query and a whole transaction.
+	QueryTimeout time.Duration `env:"PG_QUERY_TIMEOUT" default:"2s" validate:"min=0s" desc:"Client-side deadline of a single query."`
+	TxTimeout    time.Duration `env:"PG_TX_TIMEOUT" default:"4s" validate:"min=0s" desc:"Client-side deadline of a whole transaction, retries included."`
+	// Server-side session settings; 0 keeps the server default.
+	StatementTimeout time.Duration `env:"PG_STATEMENT_TIMEOUT" default:"0s" validate:"min=0s" desc:"Server-side statement_timeout; 0 keeps the server default."`
+	LockTimeout      time.Duration `env:"PG_LOCK_TIMEOUT" default:"0s" validate:"min=0s" desc:"Server-side lock_timeout; a contended lock then fails with a retryable 503."`
+}
+
+// BalanceCacheConfig sizes the in-process balance cache.
+//
+//nolint:lll
+type BalanceCacheConfig struct {
+	Enabled bool          `env:"BALANCE_CACHE_ENABLED" default:"false" desc:"Cache balances in process, kept fresh across instances via LISTEN/NOTIFY."`
+	TTL     time.Duration `env:"BALANCE_CACHE_TTL" default:"30s" validate:"min=1ms" desc:"Lifetime of a cached balance."`
+	MaxSize int           `env:"BALANCE_CACHE_MAX_SIZE" default:"10000" validate:"min=1" desc:"Maximum number of cached balances."`
+}
+
+// PartitionConfig drives the transactions partition maintenance job.
+//
+//nolint:lll
+type PartitionConfig struct {
+	// MonthsAhead is how many months past the current one get a partition
+	// before any row needs it.
+	MonthsAhead int `env:"TX_PARTITIONS_AHEAD" default:"3" validate:"min=0" desc:"Months past the current one to create partitions for."`
+	// IdempotencyWindow is how long full transaction records stay in the live
+	// table. Older partitions are archived and duplicates of their ids are
+	// caught by transaction_keys alone. 0 disables archiving.
+	IdempotencyWindow time.Duration `env:"TX_IDEMPOTENCY_WINDOW" default:"2160h" validate:"min=0s" desc:"How long full transaction records stay live before archiving; 0 disables archiving."`
+	// KeyRetention is how long a transaction id stays in transaction_keys, so
+	// how long a duplicate of it is rejected. It must not be shorter than
+	// IdempotencyWindow. 0 keeps the ids forever.
+	KeyRetention time.Duration `env:"TX_KEY_RETENTION" default:"8760h" validate:"min=0s" desc:"How long a transaction id is remembered to reject duplicates; at least TX_IDEMPOTENCY_WINDOW, 0 keeps ids forever."`
 }
 
 type LoggerConfig struct {
-	LogLevel slog.Level `env:"APP_LOG_LEVEL"`
+	LogLevel slog.Level `env:"APP_LOG_LEVEL" default:"INFO" desc:"Log level: DEBUG, INFO, WARN or ERROR."`
+}
+
+//nolint:lll
+type TracingConfig struct {
+	Exporter        string `env:"OTEL_TRACES_EXPORTER" default:"none" desc:"Trace exporter: none, stdout or otlp."`
+	MetricsExporter string `env:"OTEL_METRICS_EXPORTER" default:"none" desc:"Metrics exporter (cache hits, misses, evictions): none, stdout or otlp."`
+	OTLPEndpoint    string `env:"OTEL_EXPORTER_OTLP_ENDPOINT" required:"false" validate:"url" desc:"OTLP endpoint; empty uses the exporter default (localhost:4318)."`
+	ServiceName     string `env:"OTEL_SERVICE_NAME" default:"balance-api" desc:"Service name reported in traces."`
 }
+
+// Storage modes selectable with STORAGE.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Postgres backends selectable with PG_DRIVER.
+const (
+	DriverStdlib  = "stdlib"
+	DriverPgxPool = "pgxpool"
+)
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+// Variables naming the optional files every command reads its settings from.
+const (
+	// ConfigFileEnv names a YAML or JSON file of variable names to values.
+	ConfigFileEnv = "CONFIG_FILE"
+	// EnvFileEnv names a dotenv file, e.g. ENV_FILE=.env.dev for local runs.
+	// No dotenv file is read unless it is set: a checkout's dev settings
+	// must never leak into a command run against a real database. A named
+	// file that does not exist is an error.
+	EnvFileEnv = "ENV_FILE"
+)
+
+// Sources returns the envconf options shared by all commands. Values are
+// taken, highest priority first, from flags in args, the environment,
+// CONFIG_FILE and ENV_FILE.
+func Sources(args []string) []envconf.Option {
+	return []envconf.Option{
+		envconf.WithFlags(args),
+		envconf.WithFile(os.Getenv(ConfigFileEnv)),
+		envconf.WithDotEnv(os.Getenv(EnvFileEnv)),
+	}
+}
@@ -0,0 +1,233 @@
+// Package cache provides a bounded, TTL-based, in-process LRU cache with
+// hit/miss accounting.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Cache is a concurrency-safe LRU cache whose entries also expire after a
+// fixed TTL. The zero value is not usable; create one with New.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	order   *list.List // front = most recently used
+	items   map[K]*list.Element
+	now     func() time.Time
+	// suspended turns the cache into a pass-through: see Suspend.
+	suspended bool
+
+	hits, misses, evictions atomic.Uint64
+	counters                counters
+}
+
+// Stats is a snapshot of the cache counters.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// counters mirrors Stats to OpenTelemetry. They are no-ops unless a global
+// MeterProvider is installed, which tracing.Setup does when
+// OTEL_METRICS_EXPORTER is set.
+type counters struct {
+	hits, misses, evictions metric.Int64Counter
+	attrs                   metric.AddOption
+}
+
+const meterScope = "github.com/fastprodman/EntainHW/internal/infra/cache"
+
+// New returns a cache holding at most maxSize entries for at most ttl each.
+// name labels the cache's metrics.
+func New[K comparable, V any](name string, maxSize int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:      ttl,
+		maxSize:  max(1, maxSize),
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+		now:      time.Now,
+		counters: newCounters(name),
+	}
+}
+
+// Get returns the cached value for key, if present and not expired.
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suspended {
+		c.misses.Add(1)
+		c.counters.misses.Add(ctx, 1, c.counters.attrs)
+
+		var zero V
+
+		return zero, false
+	}
+
+	el, ok := c.items[key]
+	if ok && c.now().After(el.Value.(*entry[K, V]).expires) { //nolint:forcetypeassert
+		c.removeLocked(el)
+
+		ok = false
+	}
+
+	if !ok {
+		c.misses.Add(1)
+		c.counters.misses.Add(ctx, 1, c.counters.attrs)
+
+		var zero V
+
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	c.counters.hits.Add(ctx, 1, c.counters.attrs)
+
+	return el.Value.(*entry[K, V]).value, true //nolint:forcetypeassert
+}
+
+// Set stores value under key, evicting the least recently used entry when the
+// cache is full.
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(ctx, key, value)
+}
+
+// Add stores value under key only if no live entry exists, and reports
+// whether it did. Use it to fill the cache from reads that may race with
+// newer Sets.
+func (c *Cache[K, V]) Add(ctx context.Context, key K, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok && !c.now().After(el.Value.(*entry[K, V]).expires) { //nolint:forcetypeassert
+		return false
+	}
+
+	if c.suspended {
+		return false
+	}
+
+	c.setLocked(ctx, key, value)
+
+	return true
+}
+
+func (c *Cache[K, V]) setLocked(ctx context.Context, key K, value V) {
+	if c.suspended {
+		return
+	}
+
+	expires := c.now().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V]) //nolint:forcetypeassert
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.maxSize {
+		c.removeLocked(c.order.Back())
+		c.evictions.Add(1)
+		c.counters.evictions.Add(ctx, 1, c.counters.attrs)
+	}
+}
+
+// Delete removes key.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeLocked(el)
+	}
+}
+
+// Purge removes every entry.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.items)
+}
+
+// Suspend empties the cache and makes it a pass-through until Resume: Get
+// misses and Set and Add store nothing. Use it while the cache cannot be
+// kept fresh, e.g. when the source of invalidations is unreachable.
+func (c *Cache[K, V]) Suspend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.suspended = true
+	c.order.Init()
+	clear(c.items)
+}
+
+// Resume makes a suspended cache store and serve entries again. It starts
+// empty.
+func (c *Cache[K, V]) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.suspended = false
+}
+
+// Stats returns the current counters.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+func (c *Cache[K, V]) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key) //nolint:forcetypeassert
+}
+
+func newCounters(name string) counters {
+	meter := otel.Meter(meterScope)
+
+	// Instrument creation only fails on invalid names, which are constant here.
+	hits, _ := meter.Int64Counter("cache.hits", metric.WithDescription("Cache lookups that found a live entry."))
+	misses, _ := meter.Int64Counter("cache.misses", metric.WithDescription("Cache lookups that found nothing."))
+	evictions, _ := meter.Int64Counter("cache.evictions", metric.WithDescription("Entries evicted to respect the size bound."))
+
+	return counters{
+		hits:      hits,
+		misses:    misses,
+		evictions: evictions,
+		attrs:     metric.WithAttributes(attribute.String("cache.name", name)),
+	}
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	t.Parallel()
+
+	c := New[string, int]("test", 10, time.Minute)
+
+	if _, ok := c.Get(t.Context(), "a"); ok {
+		t.Fatal("empty cache returned a value")
+	}
+
+	c.Set(t.Context(), "a", 1)
+	c.Set(t.Context(), "a", 2)
+
+	got, ok := c.Get(t.Context(), "a")
+	if !ok || got != 2 {
+		t.Fatalf("Get(a) = %d, %v; want 2, true", got, ok)
+	}
+
+	c.Delete("a")
+
+	if _, ok := c.Get(t.Context(), "a"); ok {
+		t.Fatal("deleted key still present")
+	}
+
+	want := Stats{Hits: 1, Misses: 2, Size: 0}
+	if st := c.Stats(); st != want {
+		t.Fatalf("stats = %+v, want %+v", st, want)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+
+	c := New[string, int]("test", 10, time.Second)
+	c.now = func() time.Time { return now }
+
+	c.Set(t.Context(), "a", 1)
+
+	now = now.Add(time.Second)
+	if _, ok := c.Get(t.Context(), "a"); !ok {
+		t.Fatal("entry expired at exactly its TTL")
+	}
+
+	now = now.Add(time.Nanosecond)
+	if _, ok := c.Get(t.Context(), "a"); ok {
+		t.Fatal("entry survived its TTL")
+	}
+
+	if st := c.Stats(); st.Size != 0 {
+		t.Fatalf("expired entry not removed, size %d", st.Size)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c := New[string, int]("test", 2, time.Minute)
+
+	c.Set(t.Context(), "a", 1)
+	c.Set(t.Context(), "b", 2)
+	c.Get(t.Context(), "a") // b is now the least recently used
+	c.Set(t.Context(), "c", 3)
+
+	if _, ok := c.Get(t.Context(), "b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(t.Context(), k); !ok {
+			t.Fatalf("%s was evicted", k)
+		}
+	}
+
+	if st := c.Stats(); st.Evictions != 1 || st.Size != 2 {
+		t.Fatalf("stats = %+v, want 1 eviction and size 2", st)
+	}
+
+	c.Purge()
+
+	if st := c.Stats(); st.Size != 0 {
+		t.Fatalf("purge left %d entries", st.Size)
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	c := New[int, int]("test", 64, time.Minute)
+
+	var wg sync.WaitGroup
+
+	for w := range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 1000 {
+				c.Set(t.Context(), (w*1000+i)%100, i)
+				c.Get(t.Context(), i%100)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if st := c.Stats(); st.Size > 64 {
+		t.Fatalf("size %d exceeds bound", st.Size)
+	}
+}
+
+func TestCache_AddKeepsLiveEntry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+
+	c := New[string, int]("test", 10, time.Second)
+	c.now = func() time.Time { return now }
+
+	if !c.Add(t.Context(), "a", 1) {
+		t.Fatal("Add to empty cache must store")
+	}
+
+	if c.Add(t.Context(), "a", 2) {
+		t.Fatal("Add must not overwrite a live entry")
+	}
+
+	now = now.Add(2 * time.Second)
+
+	if !c.Add(t.Context(), "a", 3) {
+		t.Fatal("Add must replace an expired entry")
+	}
+
+	if got, _ := c.Get(t.Context(), "a"); got != 3 {
+		t.Fatalf("Get(a) = %d, want 3", got)
+	}
+}
+
+// TestCache_Metrics checks the counters reach an installed MeterProvider, as
+// they do in cmd/api once OTEL_METRICS_EXPORTER is set.
+//
+//nolint:paralleltest // replaces the global MeterProvider
+func TestCache_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	c := New[string, int]("metrics", 1, time.Minute)
+
+	c.Get(t.Context(), "a")
+	c.Set(t.Context(), "a", 1)
+	c.Get(t.Context(), "a")
+	c.Set(t.Context(), "b", 2)
+
+	var rm metricdata.ResourceMetrics
+
+	err := reader.Collect(t.Context(), &rm)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	got := map[string]int64{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+
+			for _, dp := range sum.DataPoints {
+				if v, _ := dp.Attributes.Value("cache.name"); v.AsString() == "metrics" {
+					got[m.Name] += dp.Value
+				}
+			}
+		}
+	}
+
+	want := map[string]int64{"cache.hits": 1, "cache.misses": 1, "cache.evictions": 1}
+	if !maps.Equal(got, want) {
+		t.Fatalf("exported counters = %v, want %v", got, want)
+	}
+}
+
+func TestCache_Suspend(t *testing.T) {
+	t.Parallel()
+
+	c := New[string, int]("test", 10, time.Minute)
+	c.Set(t.Context(), "a", 1)
+
+	c.Suspend()
+
+	if _, ok := c.Get(t.Context(), "a"); ok {
+		t.Fatal("suspended cache served an entry from before Suspend")
+	}
+
+	c.Set(t.Context(), "b", 2)
+
+	if c.Add(t.Context(), "c", 3) {
+		t.Fatal("suspended cache accepted Add")
+	}
+
+	if _, ok := c.Get(t.Context(), "b"); ok {
+		t.Fatal("suspended cache stored a Set")
+	}
+
+	c.Resume()
+
+	if st := c.Stats(); st.Size != 0 {
+		t.Fatalf("resumed cache holds %d entries, want 0", st.Size)
+	}
+
+	c.Set(t.Context(), "a", 4)
+
+	if got, ok := c.Get(t.Context(), "a"); !ok || got != 4 {
+		t.Fatalf("Get(a) after Resume = %d, %v; want 4, true", got, ok)
+	}
+}
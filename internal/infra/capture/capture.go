@@ -0,0 +1,176 @@
+// Package capture records HTTP requests and their responses as JSON lines,
+// one Record per line, so captured traffic can be replayed elsewhere.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record is one captured request and the response it got.
+type Record struct {
+	Time      time.Time   `json:"time"`
+	RequestID string      `json:"requestId,omitempty"`
+	Method    string      `json:"method"`
+	Path      string      `json:"path"`
+	Header    http.Header `json:"header"`
+	Body      string      `json:"body"`
+	// BodyTruncated is set when the body exceeded the capture limit; such
+	// records cannot be replayed faithfully.
+	BodyTruncated bool   `json:"bodyTruncated,omitempty"`
+	Status        int    `json:"status"`
+	Response      string `json:"response,omitempty"`
+}
+
+// Writer appends redacted Records to an underlying writer. It is safe for
+// concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	redact []string
+}
+
+// Redacted replaces the values of redacted headers.
+const Redacted = "[REDACTED]"
+
+// maxLineSize bounds a single record when reading a capture file.
+const maxLineSize = 4 << 20
+
+// DefaultRedactedHeaders are always redacted, in addition to any passed to
+// NewWriter or Open.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// NewWriter returns a Writer appending to w. Values of the
+// DefaultRedactedHeaders and of redact are replaced with Redacted.
+func NewWriter(w io.Writer, redact ...string) *Writer {
+	names := make([]string, 0, len(DefaultRedactedHeaders)+len(redact))
+	for _, h := range slices.Concat(DefaultRedactedHeaders, redact) {
+		names = append(names, http.CanonicalHeaderKey(h))
+	}
+
+	return &Writer{w: w, redact: names}
+}
+
+// Open returns a Writer appending to the file at path, creating it if needed.
+func Open(path string, redact ...string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("open capture file: %w", err)
+	}
+
+	return NewWriter(f, redact...), nil
+}
+
+// Write redacts rec and appends it as one line.
+func (w *Writer) Write(rec Record) error {
+	rec.Header = w.redactHeader(rec.Header)
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// A single write per record keeps lines whole under O_APPEND.
+	_, err = w.w.Write(line)
+	if err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (w *Writer) Close() error {
+	c, ok := w.w.(io.Closer)
+	if !ok {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := c.Close()
+	if err != nil {
+		return fmt.Errorf("close capture: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Writer) redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+
+	for _, name := range w.redact {
+		if vs, ok := out[name]; ok {
+			for i := range vs {
+				vs[i] = Redacted
+			}
+		}
+	}
+
+	return out
+}
+
+// Read decodes every record in r, in order.
+func Read(r io.Reader) ([]Record, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	var records []Record
+
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+
+		err := json.Unmarshal(sc.Bytes(), &rec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		records = append(records, rec)
+	}
+
+	err := sc.Err()
+	if err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("record longer than %d bytes: %w", maxLineSize, err)
+		}
+
+		return nil, fmt.Errorf("read records: %w", err)
+	}
+
+	return records, nil
+}
+
+// ReadFile decodes every record in the file at path.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("open capture file: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,100 @@
+package capture
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteRedactsAndRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, "x-provider-token")
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret")
+	h.Set("X-Provider-Token", "secret")
+	h.Set("Source-Type", "game")
+
+	err := w.Write(Record{Method: http.MethodPost, Path: "/user/1/transaction", Header: h, Body: `{"a":1}`, Status: 200})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if h.Get("Authorization") != "Bearer secret" {
+		t.Fatal("Write modified the caller's header")
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("secret leaked into capture: %s", buf.String())
+	}
+
+	recs, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(recs) != 1 {
+		t.Fatalf("got %d records, want 1", len(recs))
+	}
+
+	got := recs[0]
+
+	for name, want := range map[string]string{
+		"Authorization":    Redacted,
+		"X-Provider-Token": Redacted,
+		"Source-Type":      "game",
+	} {
+		if v := got.Header.Get(name); v != want {
+			t.Errorf("header %s = %q, want %q", name, v, want)
+		}
+	}
+
+	if got.Body != `{"a":1}` || got.Status != 200 || got.Path != "/user/1/transaction" {
+		t.Errorf("record = %+v", got)
+	}
+}
+
+func TestWriteConcurrentLinesStayWhole(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+
+	var wg sync.WaitGroup
+
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_ = w.Write(Record{Method: http.MethodPost, Body: strings.Repeat("x", 1000)})
+		}()
+	}
+
+	wg.Wait()
+
+	recs, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(recs) != 50 {
+		t.Fatalf("got %d records, want 50", len(recs))
+	}
+}
+
+func TestReadReportsLine(t *testing.T) {
+	t.Parallel()
+
+	_, err := Read(strings.NewReader("{\"method\":\"POST\"}\n\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("err = %v, want an error naming line 3", err)
+	}
+}
@@ -0,0 +1,152 @@
+// Package health implements liveness, readiness and startup probes.
+//
+// Liveness only says the process is running. Startup flips once the service
+// finished initialising. Readiness additionally runs dependency checks (with a
+// timeout) and turns false as soon as graceful shutdown starts, so load
+// balancers drain traffic before the HTTP server stops accepting it.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe statuses reported in Report.Status and CheckResult.Status.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusFailed      = "failed"
+)
+
+var (
+	ErrNotStarted = errors.New("startup not complete")
+	ErrDraining   = errors.New("shutting down")
+)
+
+// Check is a single named readiness dependency check.
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one Check.
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// Report is the JSON body returned by the probe endpoints.
+type Report struct {
+	Status string                 `json:"status"`
+	Reason string                 `json:"reason,omitempty"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// OK reports whether the probe passed.
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// Probes tracks the service lifecycle and runs readiness checks.
+type Probes struct {
+	timeout  time.Duration
+	checks   []Check
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// New returns Probes that run checks concurrently, each bounded by timeout.
+func New(timeout time.Duration, checks ...Check) *Probes {
+	return &Probes{timeout: timeout, checks: checks}
+}
+
+// MarkStarted flips the startup probe (and enables readiness).
+func (p *Probes) MarkStarted() { p.started.Store(true) }
+
+// MarkDraining makes readiness fail from now on. Call it first thing during
+// graceful shutdown.
+func (p *Probes) MarkDraining() { p.draining.Store(true) }
+
+// Live always reports ok: if the process can answer, it is alive.
+func (p *Probes) Live() Report {
+	return Report{Status: StatusOK}
+}
+
+// Startup reports ok once MarkStarted has been called.
+func (p *Probes) Startup() Report {
+	if !p.started.Load() {
+		return Report{Status: StatusUnavailable, Reason: ErrNotStarted.Error()}
+	}
+
+	return Report{Status: StatusOK}
+}
+
+// Ready runs all checks and reports ok only if the service has started, is
+// not draining and every check passed.
+func (p *Probes) Ready(ctx context.Context) Report {
+	if p.draining.Load() {
+		return Report{Status: StatusUnavailable, Reason: ErrDraining.Error()}
+	}
+
+	if !p.started.Load() {
+		return Report{Status: StatusUnavailable, Reason: ErrNotStarted.Error()}
+	}
+
+	results := p.runChecks(ctx)
+
+	rep := Report{Status: StatusOK, Checks: results}
+
+	for _, res := range results {
+		if res.Status != StatusOK {
+			rep.Status = StatusUnavailable
+
+			break
+		}
+	}
+
+	return rep
+}
+
+func (p *Probes) runChecks(ctx context.Context) map[string]CheckResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(p.checks))
+	)
+
+	for _, c := range p.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res := p.runCheck(ctx, c)
+
+			mu.Lock()
+			results[c.Name] = res
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func (p *Probes) runCheck(ctx context.Context, c Check) CheckResult {
+	cctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Fn(cctx)
+	res := CheckResult{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = err.Error()
+	}
+
+	return res
+}
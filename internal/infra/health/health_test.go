@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProbes_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	p := New(time.Second, Check{Name: "noop", Fn: func(context.Context) error { return nil }})
+
+	if !p.Live().OK() {
+		t.Fatalf("live must always be ok")
+	}
+
+	if p.Startup().OK() || p.Ready(t.Context()).OK() {
+		t.Fatalf("startup/ready must fail before MarkStarted")
+	}
+
+	p.MarkStarted()
+
+	if !p.Startup().OK() {
+		t.Fatalf("startup must be ok after MarkStarted")
+	}
+
+	rep := p.Ready(t.Context())
+	if !rep.OK() || rep.Checks["noop"].Status != StatusOK {
+		t.Fatalf("ready must be ok with passing checks: %+v", rep)
+	}
+
+	p.MarkDraining()
+
+	rep = p.Ready(t.Context())
+	if rep.OK() || rep.Reason != ErrDraining.Error() {
+		t.Fatalf("ready must fail while draining: %+v", rep)
+	}
+
+	if !p.Live().OK() {
+		t.Fatalf("live must stay ok while draining")
+	}
+}
+
+func TestProbes_ReadyBreakdown(t *testing.T) {
+	t.Parallel()
+
+	p := New(50*time.Millisecond,
+		Check{Name: "good", Fn: func(context.Context) error { return nil }},
+		Check{Name: "bad", Fn: func(context.Context) error { return errors.New("boom") }},
+		Check{Name: "slow", Fn: func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		}},
+	)
+	p.MarkStarted()
+
+	rep := p.Ready(t.Context())
+	if rep.OK() {
+		t.Fatalf("ready must fail when a check fails: %+v", rep)
+	}
+
+	want := map[string]string{"good": StatusOK, "bad": StatusFailed, "slow": StatusFailed}
+	for name, status := range want {
+		if rep.Checks[name].Status != status {
+			t.Fatalf("check %q: want %s, got %+v", name, status, rep.Checks[name])
+		}
+	}
+
+	if rep.Checks["bad"].Error != "boom" {
+		t.Fatalf("check error not reported: %+v", rep.Checks["bad"])
+	}
+}
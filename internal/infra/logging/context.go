@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type scopeKey struct{}
+
+// scope is a mutable, request-scoped set of log attributes. It is created
+// once per request by middleware and filled in as the request is parsed, so
+// that logs emitted anywhere below (service, repos) and the final access log
+// all carry the same identifiers.
+type scope struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithScope returns a child context carrying a fresh attribute scope seeded
+// with attrs. Use AddAttrs to extend it later.
+func WithScope(ctx context.Context, attrs ...slog.Attr) context.Context {
+	s := &scope{attrs: append(make([]slog.Attr, 0, len(attrs)+4), attrs...)}
+
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// AddAttrs appends attrs to the scope carried by ctx. It is a no-op when ctx
+// has no scope.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// Attrs returns a copy of the attributes in the scope carried by ctx.
+func Attrs(ctx context.Context) []slog.Attr {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]slog.Attr(nil), s.attrs...)
+}
+
+// ContextHandler decorates a slog.Handler with request-scoped attributes taken
+// from the record's context (see WithScope) and, when a span is active, the
+// trace and span IDs.
+type ContextHandler struct {
+	next slog.Handler
+}
+
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+//nolint:gocritic // slog.Handler signature
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(Attrs(ctx)...)
+
+		sc := trace.SpanContextFromContext(ctx)
+		if sc.IsValid() {
+			r.AddAttrs(
+				slog.String("traceId", sc.TraceID().String()),
+				slog.String("spanId", sc.SpanID().String()),
+			)
+		}
+	}
+
+	return h.next.Handle(ctx, r) //nolint:wrapcheck
+}
+
+//nolint:ireturn
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+//nolint:ireturn
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
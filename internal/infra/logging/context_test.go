@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(NewContextHandler(slog.NewJSONHandler(buf, nil)))
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var m map[string]any
+
+	err := json.Unmarshal(buf.Bytes(), &m)
+	if err != nil {
+		t.Fatalf("decode log line %q: %v", buf.String(), err)
+	}
+
+	return m
+}
+
+func TestContextHandler_AttachesScopeAttrs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := newTestLogger(&buf)
+
+	ctx := WithScope(context.Background(), slog.String("requestId", "req-1"))
+	AddAttrs(ctx, slog.Uint64("userId", 42), slog.String("source", "game"))
+
+	logger.InfoContext(ctx, "deep in the repo")
+
+	got := decodeLine(t, &buf)
+
+	want := map[string]any{"requestId": "req-1", "userId": float64(42), "source": "game"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("attr %q: want %v, got %v (line %s)", k, v, got[k], buf.String())
+		}
+	}
+}
+
+func TestContextHandler_NoScopeIsPassthrough(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	newTestLogger(&buf).InfoContext(context.Background(), "plain")
+
+	got := decodeLine(t, &buf)
+	if _, ok := got["requestId"]; ok {
+		t.Fatalf("unexpected requestId in %s", buf.String())
+	}
+
+	// AddAttrs without a scope must not panic.
+	AddAttrs(context.Background(), slog.String("k", "v"))
+}
+
+func TestContextHandler_AddsTraceIDs(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9},
+		SpanID:  trace.SpanID{0x01},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	newTestLogger(&buf).InfoContext(ctx, "traced")
+
+	got := decodeLine(t, &buf)
+	if got["traceId"] != sc.TraceID().String() || got["spanId"] != sc.SpanID().String() {
+		t.Fatalf("missing trace ids in %s", buf.String())
+	}
+}
+
+func TestAttrs_ReturnsCopy(t *testing.T) {
+	t.Parallel()
+
+	ctx := WithScope(context.Background(), slog.String("a", "1"))
+
+	attrs := Attrs(ctx)
+	attrs[0] = slog.String("a", "mutated")
+
+	if Attrs(ctx)[0].Value.String() != "1" {
+		t.Fatalf("Attrs must return a copy")
+	}
+}
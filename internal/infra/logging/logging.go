@@ -5,10 +5,13 @@ import (
 	"os"
 )
 
-// SetupJSON sets slog's default logger to use JSON output at the given level.
-func SetupJSON(level slog.Level) {
-	logger := slog.New(
+// SetupJSON sets slog's default logger to use JSON output at the given level;
+// pass a *slog.LevelVar to change the level while the program runs.
+// Request-scoped attributes from the log call's context are attached
+// automatically (see ContextHandler), so prefer the *Context slog functions.
+func SetupJSON(level slog.Leveler) {
+	logger := slog.New(NewContextHandler(
 		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}),
-	)
+	))
 	slog.SetDefault(logger)
 }
@@ -0,0 +1,145 @@
+// Package memtx gives the in-memory repos the transaction semantics the
+// Postgres ones get from the database: per-key locks held until the
+// transaction ends, like row locks, and writes that become visible to other
+// callers only on commit.
+package memtx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+)
+
+// Lock is a context-aware mutex that a transaction can hold until it ends.
+type Lock struct{ ch chan struct{} }
+
+// Tx is an in-memory transaction. Repos reach it through FromContext.
+type Tx struct {
+	held   []*Lock
+	locked map[*Lock]struct{}
+	values map[any]any
+	onEnd  []func(committed bool)
+}
+
+type txKey struct{}
+
+type txManager struct{}
+
+var _ txn.Manager = (*txManager)(nil)
+
+func NewLock() *Lock {
+	return &Lock{ch: make(chan struct{}, 1)}
+}
+
+func (l *Lock) acquire(ctx context.Context) error {
+	select {
+	case l.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("acquire lock: %w", ctx.Err())
+	}
+}
+
+func (l *Lock) release() { <-l.ch }
+
+// NewTxManager returns a txn.Manager for the in-memory repos.
+func NewTxManager() *txManager {
+	return &txManager{}
+}
+
+func (m *txManager) WithTx(ctx context.Context, fn func(context.Context) error, opts ...txn.Option) error {
+	return WithTx(ctx, fn, opts...)
+}
+
+// WithTx runs fn inside a transaction, committing if it returns nil.
+//
+// Only txn.WithTimeout has an effect: locks already make every transaction
+// serializable, and nothing in memory fails in a way worth retrying. If ctx
+// already carries a transaction, fn joins it.
+func WithTx(ctx context.Context, fn func(context.Context) error, opts ...txn.Option) error {
+	if FromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	o := txn.NewOptions(opts...)
+
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	tx := &Tx{locked: map[*Lock]struct{}{}, values: map[any]any{}}
+
+	err := fn(context.WithValue(ctx, txKey{}, tx))
+	tx.end(err == nil)
+
+	return err
+}
+
+// FromContext returns the transaction carried by ctx, or nil.
+func FromContext(ctx context.Context) *Tx {
+	tx, _ := ctx.Value(txKey{}).(*Tx)
+
+	return tx
+}
+
+// Locked runs fn holding l. Inside a transaction l stays held until the
+// transaction ends; otherwise it is released when fn returns, and fn gets a
+// nil Tx. Waiting for l gives up when ctx is done.
+func Locked(ctx context.Context, l *Lock, fn func(tx *Tx) error) error {
+	tx := FromContext(ctx)
+
+	if tx == nil {
+		err := l.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer l.release()
+
+		return fn(nil)
+	}
+
+	if _, ok := tx.locked[l]; !ok {
+		err := l.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		tx.locked[l] = struct{}{}
+		tx.held = append(tx.held, l)
+	}
+
+	return fn(tx)
+}
+
+// Get returns the value staged under key in this transaction.
+func (t *Tx) Get(key any) (any, bool) {
+	v, ok := t.values[key]
+
+	return v, ok
+}
+
+// Set stages value under key; it is only visible through Get until the
+// transaction ends.
+func (t *Tx) Set(key, value any) {
+	t.values[key] = value
+}
+
+// OnEnd registers fn to run when the transaction commits or rolls back,
+// before its locks are released. Hooks run in registration order.
+func (t *Tx) OnEnd(fn func(committed bool)) {
+	t.onEnd = append(t.onEnd, fn)
+}
+
+func (t *Tx) end(committed bool) {
+	for _, fn := range t.onEnd {
+		fn(committed)
+	}
+
+	for i := len(t.held) - 1; i >= 0; i-- {
+		t.held[i].release()
+	}
+}
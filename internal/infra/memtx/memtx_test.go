@@ -0,0 +1,116 @@
+package memtx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+)
+
+func TestWithTx_HooksSeeOutcome(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name          string
+		fnErr         error
+		wantCommitted bool
+	}{
+		{name: "commit", wantCommitted: true},
+		{name: "rollback", fnErr: errBoom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var committed, called bool
+
+			err := WithTx(t.Context(), func(ctx context.Context) error {
+				FromContext(ctx).OnEnd(func(c bool) { committed, called = c, true })
+
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) {
+				t.Fatalf("want %v, got %v", tt.fnErr, err)
+			}
+
+			if !called || committed != tt.wantCommitted {
+				t.Fatalf("hook: called %v committed %v, want committed %v", called, committed, tt.wantCommitted)
+			}
+		})
+	}
+}
+
+func TestWithTx_NestedJoinsOuter(t *testing.T) {
+	t.Parallel()
+
+	err := WithTx(t.Context(), func(outer context.Context) error {
+		return WithTx(outer, func(inner context.Context) error {
+			if FromContext(inner) != FromContext(outer) {
+				t.Fatal("nested WithTx must join the outer transaction")
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("with tx: %v", err)
+	}
+}
+
+// A lock taken inside a transaction is held until it ends, so a second
+// transaction waiting for it times out.
+func TestLocked_HeldUntilTxEnds(t *testing.T) {
+	t.Parallel()
+
+	l := NewLock()
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- WithTx(t.Context(), func(ctx context.Context) error {
+			err := Locked(ctx, l, func(*Tx) error { return nil })
+			if err != nil {
+				return err
+			}
+
+			close(locked)
+			<-release
+
+			// Re-entering a held lock does not block.
+			return Locked(ctx, l, func(*Tx) error { return nil })
+		})
+	}()
+
+	<-locked
+
+	err := WithTx(t.Context(), func(ctx context.Context) error {
+		return Locked(ctx, l, func(*Tx) error { return nil })
+	}, txn.WithTimeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded while the lock is held, got %v", err)
+	}
+
+	close(release)
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("holder: %v", err)
+	}
+
+	err = Locked(t.Context(), l, func(tx *Tx) error {
+		if tx != nil {
+			t.Fatal("outside a transaction fn gets a nil Tx")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package pgtestutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Fixture declares rows to seed. Users are inserted before transactions.
+type Fixture struct {
+	Users        []User
+	Transactions []Transaction
+}
+
+type User struct {
+	ID      uint64
+	Balance money.Amount
+}
+
+// Transaction is a processed transaction ID. It is recorded like the service
+// records it: reserved in transaction_keys and stored in transactions.
+type Transaction struct {
+	ID     string
+	UserID uint64
+	// CreatedAt defaults to now; a partition must exist for it.
+	CreatedAt time.Time
+}
+
+// execFunc runs one statement.
+type execFunc func(ctx context.Context, query string, args ...any) error
+
+// Seed inserts f into db, failing t on any error.
+func Seed(t testing.TB, db *sql.DB, f Fixture) {
+	t.Helper()
+
+	seed(t, f, func(ctx context.Context, query string, args ...any) error {
+		_, err := db.ExecContext(ctx, query, args...)
+
+		return err //nolint:wrapcheck
+	})
+}
+
+// SeedPool is Seed for a pgxpool.
+func SeedPool(t testing.TB, pool *pgxpool.Pool, f Fixture) {
+	t.Helper()
+
+	seed(t, f, func(ctx context.Context, query string, args ...any) error {
+		_, err := pool.Exec(ctx, query, args...)
+
+		return err //nolint:wrapcheck
+	})
+}
+
+func seed(t testing.TB, f Fixture, exec execFunc) {
+	t.Helper()
+
+	ctx := t.Context()
+
+	for _, u := range f.Users {
+		err := exec(ctx, `INSERT INTO users (id, balance) VALUES ($1, $2)`, u.ID, u.Balance)
+		if err != nil {
+			t.Fatalf("seed user %d: %v", u.ID, err)
+		}
+	}
+
+	for _, tx := range f.Transactions {
+		createdAt := tx.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		err := exec(ctx, `
+			WITH claim AS (
+				INSERT INTO transaction_keys (transaction_id, created_at)
+				VALUES ($1, $3)
+				RETURNING transaction_id, created_at
+			)
+			INSERT INTO transactions (transaction_id, user_id, created_at)
+			SELECT transaction_id, $2, created_at FROM claim
+		`, tx.ID, tx.UserID, createdAt)
+		if err != nil {
+			t.Fatalf("seed transaction %q: %v", tx.ID, err)
+		}
+	}
+}
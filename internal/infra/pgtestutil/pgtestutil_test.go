@@ -1,6 +1,8 @@
 package pgtestutil
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -16,3 +18,54 @@ func TestReplaceDBInDSN(t *testing.T) {
 		t.Fatalf("db not replaced: %s", out)
 	}
 }
+
+func TestDSN_EnvOverride(t *testing.T) {
+	t.Setenv(DSNEnv, "")
+
+	if got := DSN(); got != BaseDSN {
+		t.Fatalf("default: want %s, got %s", BaseDSN, got)
+	}
+
+	t.Setenv(DSNEnv, "postgres://u:p@db.example:6543/postgres")
+
+	if got := DSN(); got != "postgres://u:p@db.example:6543/postgres" {
+		t.Fatalf("override: got %s", got)
+	}
+
+	if got := redact(DSN()); strings.Contains(got, ":p@") {
+		t.Fatalf("password not redacted: %s", got)
+	}
+}
+
+func TestHashDir_TracksMigrations(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, body string) {
+		t.Helper()
+
+		err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("000001_a.up.sql", "CREATE TABLE a ();")
+	write("README.md", "ignored")
+
+	first, err := hashDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("README.md", "still ignored")
+
+	if again, _ := hashDir(dir); again != first {
+		t.Fatal("non-SQL files must not change the template name")
+	}
+
+	write("000002_b.up.sql", "CREATE TABLE b ();")
+
+	if changed, _ := hashDir(dir); changed == first {
+		t.Fatal("a new migration must change the template name")
+	}
+}
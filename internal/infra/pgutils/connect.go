@@ -4,23 +4,30 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/fastprodman/EntainHW/internal/config"
 	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
-func OpenDB(ctx context.Context, pgConfig *config.PostgresConfig) (*sql.DB, error) {
-	db, err := sql.Open("pgx", pgConfig.DSN)
+// OpenDB opens the pool and applies statement_timeout / lock_timeout to every
+// session, so a stuck query or a contended row lock fails fast instead of
+// holding the request until the HTTP write timeout. The pool is closed when
+// queue shuts down.
+func OpenDB(ctx context.Context, pgConfig *config.PostgresConfig, queue *shutdownqueue.Queue) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(pgConfig.DSN)
 	if err != nil {
-		return nil, fmt.Errorf("open connection: %w", err)
+		return nil, fmt.Errorf("parse dsn: %w", err)
 	}
 
-	db.SetMaxOpenConns(pgConfig.MaxOpenConns)
-	db.SetMaxIdleConns(pgConfig.MaxIdleConns)
-	db.SetConnMaxIdleTime(pgConfig.ConnMaxIdleTime)
-	db.SetConnMaxLifetime(pgConfig.ConnMaxLifetime)
+	ApplySessionTimeouts(connConfig, pgConfig)
+
+	db := stdlib.OpenDB(*connConfig)
+
+	SetPoolLimits(db, pgConfig)
 
 	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -33,14 +40,39 @@ func OpenDB(ctx context.Context, pgConfig *config.PostgresConfig) (*sql.DB, erro
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	shutdownqueue.Add(func(ctx context.Context) error {
+	queue.Add(func(ctx context.Context) error {
 		err := db.Close()
 		if err != nil {
 			return fmt.Errorf("close postgres: %w", err)
 		}
 
 		return nil
-	})
+	}, shutdownqueue.WithName("postgres "+connConfig.Host))
 
 	return db, nil
 }
+
+// SetPoolLimits sizes the connection pool of db from pgConfig. It may be
+// called again while db is in use, e.g. after a config reload.
+func SetPoolLimits(db *sql.DB, pgConfig *config.PostgresConfig) {
+	db.SetMaxOpenConns(pgConfig.MaxOpenConns)
+	db.SetMaxIdleConns(pgConfig.MaxIdleConns)
+	db.SetConnMaxIdleTime(pgConfig.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(pgConfig.ConnMaxLifetime)
+}
+
+// ApplySessionTimeouts sets statement_timeout and lock_timeout from pgConfig
+// as runtime parameters of every connection made with connConfig.
+func ApplySessionTimeouts(connConfig *pgx.ConnConfig, pgConfig *config.PostgresConfig) {
+	setTimeoutParam(connConfig, "statement_timeout", pgConfig.StatementTimeout)
+	setTimeoutParam(connConfig, "lock_timeout", pgConfig.LockTimeout)
+}
+
+// setTimeoutParam sets a millisecond session parameter; 0 leaves it unset.
+func setTimeoutParam(cfg *pgx.ConnConfig, name string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	cfg.RuntimeParams[name] = strconv.FormatInt(d.Milliseconds(), 10)
+}
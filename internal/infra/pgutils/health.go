@@ -0,0 +1,87 @@
+package pgutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+)
+
+var (
+	ErrPoolSaturated  = errors.New("connection pool saturated")
+	ErrSchemaDirty    = errors.New("schema migration left dirty")
+	ErrSchemaOutdated = errors.New("schema version outdated")
+)
+
+// PingCheck reports whether the database answers a ping.
+func PingCheck(db *sql.DB) health.Check {
+	return health.Check{
+		Name: "postgres.ping",
+		Fn: func(ctx context.Context) error {
+			err := db.PingContext(ctx)
+			if err != nil {
+				return fmt.Errorf("ping: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// PoolCheck fails when every connection of a bounded pool is in use and
+// callers have started queueing for one.
+func PoolCheck(db *sql.DB) health.Check {
+	var lastWaits atomic.Int64
+
+	return health.Check{
+		Name: "postgres.pool",
+		Fn: func(context.Context) error {
+			st := db.Stats()
+
+			waits := st.WaitCount - lastWaits.Swap(st.WaitCount)
+
+			if st.MaxOpenConnections > 0 && st.InUse >= st.MaxOpenConnections && waits > 0 {
+				return fmt.Errorf("%w: %d/%d in use, %d waits since last check",
+					ErrPoolSaturated, st.InUse, st.MaxOpenConnections, waits)
+			}
+
+			return nil
+		},
+	}
+}
+
+// SchemaCheck verifies that golang-migrate's schema_migrations table is at
+// least at version want and not dirty.
+func SchemaCheck(db *sql.DB, want uint) health.Check {
+	return health.Check{
+		Name: "postgres.schema",
+		Fn: func(ctx context.Context) error {
+			var (
+				version uint
+				dirty   bool
+			)
+
+			err := db.QueryRowContext(ctx, `
+				SELECT version, dirty
+				FROM schema_migrations
+				LIMIT 1
+			`).Scan(&version, &dirty)
+			if err != nil {
+				return fmt.Errorf("read schema version: %w", err)
+			}
+
+			if dirty {
+				return fmt.Errorf("%w: version %d", ErrSchemaDirty, version)
+			}
+
+			if version < want {
+				return fmt.Errorf("%w: have %d, want %d", ErrSchemaOutdated, version, want)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package pgutils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	listenBaseBackoff = 100 * time.Millisecond
+	listenMaxBackoff  = 5 * time.Second
+)
+
+// Listen subscribes to channel on a dedicated connection to dsn and calls
+// onNotify with every payload until ctx is done. The connection is
+// re-established with backoff whenever it drops. Notifications sent while
+// disconnected are lost: onConnect runs after every successful (re)subscribe
+// and onDisconnect as soon as a subscribed connection drops, so callers can
+// stop relying on notifications in between.
+func Listen(
+	ctx context.Context,
+	dsn, channel string,
+	onConnect, onDisconnect func(),
+	onNotify func(payload string),
+) {
+	for attempt := 1; ; attempt++ {
+		connected, err := listenOnce(ctx, dsn, channel, onConnect, onNotify)
+		if connected {
+			onDisconnect()
+
+			attempt = 1
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		wait := backoff(attempt, listenBaseBackoff, listenMaxBackoff)
+
+		slog.WarnContext(ctx, "listen connection lost, reconnecting",
+			"channel", channel,
+			"backoff", wait,
+			"error", err,
+		)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func listenOnce(
+	ctx context.Context,
+	dsn, channel string,
+	onConnect func(),
+	onNotify func(string),
+) (connected bool, _ error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return false, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx)) //nolint:errcheck
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return false, fmt.Errorf("listen %s: %w", channel, err)
+	}
+
+	onConnect()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("wait for notification: %w", err)
+		}
+
+		onNotify(n.Payload)
+	}
+}
@@ -0,0 +1,70 @@
+package pgutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+)
+
+// TestListen_Reconnect drops the listening connection and checks onDisconnect
+// runs before the listener subscribes again.
+func TestListen_Reconnect(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	const channel = "listen_reconnect_test"
+
+	events := make(chan string, 8)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		Listen(ctx, pgtestutil.DSN(), channel,
+			func() { events <- "connect" },
+			func() { events <- "disconnect" },
+			func(string) {},
+		)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	next := func() string {
+		t.Helper()
+
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(10 * time.Second):
+			t.Fatal("no listener event")
+
+			return ""
+		}
+	}
+
+	if e := next(); e != "connect" {
+		t.Fatalf("first event = %q, want connect", e)
+	}
+
+	_, err := db.ExecContext(t.Context(),
+		`SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE query = $1`,
+		`LISTEN "`+channel+`"`)
+	if err != nil {
+		t.Fatalf("terminate listener: %v", err)
+	}
+
+	for _, want := range []string{"disconnect", "connect"} {
+		if e := next(); e != want {
+			t.Fatalf("event = %q, want %q", e, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pgutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SQLSTATEs after which the whole transaction can simply be run again.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+
+	defaultMaxBackoff = 250 * time.Millisecond
+)
+
+// IsRetryable reports whether err is a serialization failure or deadlock,
+// i.e. the transaction can be retried from the start.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
+
+// Retry calls fn up to maxAttempts times while it fails with a retryable
+// error (see IsRetryable), sleeping a jittered exponential backoff between
+// attempts. Retries are logged and the attempt count is recorded on the
+// current span.
+func Retry(ctx context.Context, maxAttempts int, baseBackoff time.Duration, fn func(context.Context) error) error {
+	span := trace.SpanFromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+
+		span.SetAttributes(attribute.Int("db.transaction.attempts", attempt))
+
+		if err == nil {
+			if attempt > 1 {
+				slog.InfoContext(ctx, "transaction succeeded after retry", "attempts", attempt)
+			}
+
+			return nil
+		}
+
+		if !IsRetryable(err) || attempt >= maxAttempts {
+			if attempt > 1 {
+				return fmt.Errorf("after %d attempts: %w", attempt, err)
+			}
+
+			return err
+		}
+
+		wait := backoff(attempt, baseBackoff, defaultMaxBackoff)
+
+		slog.WarnContext(ctx, "retrying transaction",
+			"attempt", attempt,
+			"maxAttempts", maxAttempts,
+			"backoff", wait,
+			"error", err,
+		)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("retry aborted after %d attempts: %w", attempt, errors.Join(err, ctx.Err()))
+		}
+	}
+}
+
+// backoff returns a "full jitter" delay in [0, min(maxWait, base*2^(attempt-1))].
+func backoff(attempt int, base, maxWait time.Duration) time.Duration {
+	ceiling := base << min(attempt-1, 20)
+	if ceiling <= 0 || ceiling > maxWait {
+		ceiling = maxWait
+	}
+
+	return time.Duration(rand.Int64N(int64(ceiling) + 1)) //nolint:gosec // jitter, not crypto
+}
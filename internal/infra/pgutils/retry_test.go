@@ -0,0 +1,57 @@
+package pgutils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain", err: errors.New("boom"), want: false},
+		{name: "serialization_failure", err: &pgconn.PgError{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, want: true},
+		{name: "unique_violation", err: &pgconn.PgError{Code: "23505"}, want: false},
+		{name: "wrapped", err: fmt.Errorf("fn: %w", &pgconn.PgError{Code: "40001"}), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff_Bounds(t *testing.T) {
+	t.Parallel()
+
+	const (
+		base    = 10 * time.Millisecond
+		maxWait = 50 * time.Millisecond
+	)
+
+	for attempt := 1; attempt <= 30; attempt++ {
+		ceiling := min(maxWait, base<<min(attempt-1, 20))
+
+		for range 100 {
+			got := backoff(attempt, base, maxWait)
+			if got < 0 || got > ceiling {
+				t.Fatalf("attempt %d: backoff %v outside [0, %v]", attempt, got, ceiling)
+			}
+		}
+	}
+}
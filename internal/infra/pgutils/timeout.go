@@ -0,0 +1,97 @@
+package pgutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Timeouts are the client-side deadlines for a single query and for a whole
+// transaction; 0 disables either.
+type Timeouts struct {
+	Query time.Duration
+	Tx    time.Duration
+}
+
+// RepoOption configures a Postgres repo.
+type RepoOption func(*RepoOptions)
+
+// RepoOptions holds the settings shared by the Postgres repos.
+type RepoOptions struct {
+	// QueryTimeout bounds every single query on the client side; 0 disables it.
+	QueryTimeout time.Duration
+}
+
+const (
+	sqlStateLockNotAvailable = "55P03" // lock_timeout expired
+	sqlStateQueryCanceled    = "57014" // statement_timeout expired or query cancelled
+)
+
+// TxContext returns ctx bounded by the per-transaction timeout, for units of
+// work that do not go through WithTx.
+func (t Timeouts) TxContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Tx)
+}
+
+// WithQueryTimeout bounds every query issued by the repo.
+func WithQueryTimeout(d time.Duration) RepoOption {
+	return func(o *RepoOptions) { o.QueryTimeout = d }
+}
+
+// NewRepoOptions applies opts over the zero RepoOptions.
+func NewRepoOptions(opts ...RepoOption) RepoOptions {
+	var o RepoOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// QueryContext returns ctx bounded by the per-query timeout.
+func (o RepoOptions) QueryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, o.QueryTimeout)
+}
+
+// IsTimeout reports whether err, returned by work done under ctx, means a
+// query gave up waiting: a Postgres lock_timeout or statement_timeout, or a
+// client-side deadline. If ctx was cancelled (client went away) it is not a
+// timeout, even though pgx reports the cancelled query as 57014 too.
+func IsTimeout(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == sqlStateLockNotAvailable || pgErr.Code == sqlStateQueryCanceled
+}
+
+// MapTimeout marks timeouts (see IsTimeout) as apperr.ErrTimeout so callers
+// can answer with a retryable status. Other errors are returned unchanged.
+func MapTimeout(ctx context.Context, err error) error {
+	if err == nil || !IsTimeout(ctx, err) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", apperr.ErrTimeout, err)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,106 @@
+package pgutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsTimeout(t *testing.T) {
+	t.Parallel()
+
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	expired, cancel := context.WithDeadline(t.Context(), time.Now())
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context //nolint:containedctx
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain", err: errors.New("boom"), want: false},
+		{name: "lock_timeout", err: &pgconn.PgError{Code: "55P03"}, want: true},
+		{name: "statement_timeout", err: &pgconn.PgError{Code: "57014"}, want: true},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: true},
+		{name: "client_cancelled", err: context.Canceled, want: false},
+		{name: "serialization_failure", err: &pgconn.PgError{Code: "40001"}, want: false},
+		{name: "query_canceled_ctx_cancelled", ctx: cancelled, err: &pgconn.PgError{Code: "57014"}, want: false},
+		{name: "deadline_ctx_cancelled", ctx: cancelled, err: context.DeadlineExceeded, want: false},
+		{name: "query_canceled_ctx_expired", ctx: expired, err: &pgconn.PgError{Code: "57014"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = t.Context()
+			}
+
+			if got := IsTimeout(ctx, tt.err); got != tt.want {
+				t.Fatalf("IsTimeout(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapTimeout(t *testing.T) {
+	t.Parallel()
+
+	if MapTimeout(t.Context(), nil) != nil {
+		t.Fatal("MapTimeout(nil) must be nil")
+	}
+
+	plain := errors.New("boom")
+	if got := MapTimeout(t.Context(), plain); got != plain { //nolint:errorlint
+		t.Fatalf("non-timeout error changed: %v", got)
+	}
+
+	lockErr := &pgconn.PgError{Code: "55P03"}
+
+	got := MapTimeout(t.Context(), fmt.Errorf("lock: %w", lockErr))
+	if !errors.Is(got, apperr.ErrTimeout) {
+		t.Fatalf("want apperr.ErrTimeout, got %v", got)
+	}
+
+	if !errors.Is(got, lockErr) {
+		t.Fatalf("original error lost: %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	canceledErr := &pgconn.PgError{Code: "57014"}
+	if got := MapTimeout(ctx, canceledErr); got != canceledErr { //nolint:errorlint
+		t.Fatalf("cancelled request mapped to %v", got)
+	}
+}
+
+func TestRepoOptions_QueryContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := NewRepoOptions().QueryContext(t.Context())
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("zero timeout must not set a deadline")
+	}
+
+	ctx, cancel = NewRepoOptions(WithQueryTimeout(time.Minute)).QueryContext(t.Context())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Fatalf("deadline = %v (set: %v), want within a minute", deadline, ok)
+	}
+}
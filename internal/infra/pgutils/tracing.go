@@ -0,0 +1,25 @@
+package pgutils
+
+import (
+	"context"
+
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerScope = "github.com/fastprodman/EntainHW/internal/infra/pgutils"
+
+// StartSpan starts a client span for a single Postgres query issued by a repo.
+// Finish it with tracing.End.
+//
+//nolint:ireturn
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, tracerScope, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+		),
+	)
+}
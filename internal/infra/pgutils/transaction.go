@@ -4,17 +4,89 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Querier is the subset of *sql.DB and *sql.Tx used by the repos.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+type txManager struct{ db *sql.DB }
+
+var _ txn.Manager = (*txManager)(nil)
+
+// NewTxManager returns a txn.Manager running transactions on db.
+func NewTxManager(db *sql.DB) *txManager {
+	return &txManager{db: db}
+}
+
+func (m *txManager) WithTx(ctx context.Context, fn func(context.Context) error, opts ...txn.Option) error {
+	return WithTx(ctx, m.db, fn, opts...)
+}
+
+// ContextWithTx returns a copy of ctx carrying tx, so repo calls made with it
+// run inside tx.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+//
+//nolint:ireturn
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
 // WithTx runs fn inside a transaction.
 // It commits if fn returns nil, otherwise it rolls back.
-func WithTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
-	tx, err := db.BeginTx(ctx, nil) // default isolation level
+//
+// fn receives a context carrying the transaction (see Conn) and its span;
+// pass it on to repo calls so they join the transaction and their spans nest
+// under it. If ctx already carries a transaction, fn simply joins it and opts
+// are ignored.
+//
+// With txn.WithRetry, fn may be called several times; see txn.Manager.
+func WithTx(ctx context.Context, db *sql.DB, fn func(context.Context) error, opts ...txn.Option) (retErr error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	o := txn.NewOptions(opts...)
+
+	ctx, span := tracing.Start(ctx, tracerScope, "pgutils.WithTx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.transaction.isolation", o.Isolation.String())),
+	)
+	defer func() { tracing.End(span, retErr) }()
+
+	ctx, cancel := withTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	return Retry(ctx, o.MaxAttempts, o.BaseBackoff, func(ctx context.Context) error {
+		return runTx(ctx, db, fn, &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly})
+	})
+}
+
+func runTx(ctx context.Context, db *sql.DB, fn func(context.Context) error, txOpts *sql.TxOptions) error {
+	tx, err := db.BeginTx(ctx, txOpts)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 
-	err = fn(tx)
+	err = fn(ContextWithTx(ctx, tx))
 	if err != nil {
 		rbErr := tx.Rollback()
 		if rbErr != nil {
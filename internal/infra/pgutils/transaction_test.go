@@ -0,0 +1,184 @@
+package pgutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithTx_Retry(t *testing.T) {
+	t.Parallel()
+
+	serialization := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name      string
+		failures  int   // how many leading attempts return failErr
+		failErr   error // error returned by the failing attempts
+		attempts  int   // WithRetry maxAttempts
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "no_error", failures: 0, failErr: nil, attempts: 3, wantCalls: 1},
+		{name: "recovers", failures: 2, failErr: serialization, attempts: 3, wantCalls: 3},
+		{name: "exhausted", failures: 5, failErr: serialization, attempts: 3, wantCalls: 3, wantErr: serialization},
+		{name: "deadlock", failures: 1, failErr: &pgconn.PgError{Code: "40P01"}, attempts: 2, wantCalls: 2},
+		{name: "not_retryable", failures: 1, failErr: sql.ErrNoRows, attempts: 3, wantCalls: 1, wantErr: sql.ErrNoRows},
+		{name: "without_retry_option", failures: 1, failErr: serialization, attempts: 0, wantCalls: 1, wantErr: serialization},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, cleanup := pgtestutil.NewTestDB(t)
+			defer cleanup()
+
+			opts := []txn.Option{txn.WithIsolation(sql.LevelSerializable)}
+			if tt.attempts > 0 {
+				opts = append(opts, txn.WithRetry(tt.attempts, time.Millisecond))
+			}
+
+			calls := 0
+			err := WithTx(t.Context(), db, func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failErr
+				}
+
+				return nil
+			}, opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithTx_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	err := WithTx(t.Context(), db, func(ctx context.Context) error {
+		_, err := Conn(ctx, db).ExecContext(ctx, `INSERT INTO users (id, balance) VALUES (1, 0)`)
+
+		return err //nolint:wrapcheck
+	}, txn.ReadOnly())
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "25006" { // read_only_sql_transaction
+		t.Fatalf("err = %v, want SQLSTATE 25006", err)
+	}
+}
+
+// TestWithTx_SerializableConcurrentIncrements does read-modify-write on the
+// same row from many goroutines at SERIALIZABLE; conflicts must be absorbed
+// by the retries so that no increment is lost or surfaced as an error.
+func TestWithTx_SerializableConcurrentIncrements(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	_, err := db.Exec(`INSERT INTO users (id, balance) VALUES (1, 0)`)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+
+	const workers = 10
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, workers)
+	)
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs <- WithTx(t.Context(), db, func(ctx context.Context) error {
+				var balance int64
+
+				err := Conn(ctx, db).QueryRowContext(ctx, `SELECT balance FROM users WHERE id = 1`).Scan(&balance)
+				if err != nil {
+					return err //nolint:wrapcheck
+				}
+
+				_, err = Conn(ctx, db).ExecContext(ctx, `UPDATE users SET balance = $1 WHERE id = 1`, balance+1)
+
+				return err //nolint:wrapcheck
+			}, txn.WithIsolation(sql.LevelSerializable), txn.WithRetry(100, time.Millisecond))
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("WithTx: %v", err)
+		}
+	}
+
+	var balance int64
+
+	err = db.QueryRow(`SELECT balance FROM users WHERE id = 1`).Scan(&balance)
+	if err != nil {
+		t.Fatalf("read balance: %v", err)
+	}
+
+	if balance != workers {
+		t.Fatalf("balance = %d, want %d", balance, workers)
+	}
+}
+
+func TestWithTx_NestedJoinsOuter(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	errRollback := errors.New("rollback")
+
+	err := WithTx(t.Context(), db, func(ctx context.Context) error {
+		err := WithTx(ctx, db, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, `INSERT INTO users (id, balance) VALUES (1, 0)`)
+
+			return err //nolint:wrapcheck
+		})
+		if err != nil {
+			return err
+		}
+
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("err = %v, want %v", err, errRollback)
+	}
+
+	var n int
+
+	err = db.QueryRow(`SELECT count(*) FROM users`).Scan(&n)
+	if err != nil {
+		t.Fatalf("count users: %v", err)
+	}
+
+	if n != 0 {
+		t.Fatalf("inner insert survived the outer rollback: %d users", n)
+	}
+}
@@ -0,0 +1,81 @@
+package pgxutils
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/fastprodman/EntainHW/internal/infra/health"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PingCheck reports whether the database answers a ping.
+func PingCheck(pool *pgxpool.Pool) health.Check {
+	return health.Check{
+		Name: "postgres.ping",
+		Fn: func(ctx context.Context) error {
+			err := pool.Ping(ctx)
+			if err != nil {
+				return fmt.Errorf("ping: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// PoolCheck fails when every connection of the pool is acquired and callers
+// have started waiting for one.
+func PoolCheck(pool *pgxpool.Pool) health.Check {
+	var lastWaits atomic.Int64
+
+	return health.Check{
+		Name: "postgres.pool",
+		Fn: func(context.Context) error {
+			st := pool.Stat()
+
+			waits := st.EmptyAcquireCount() - lastWaits.Swap(st.EmptyAcquireCount())
+
+			if st.AcquiredConns() >= st.MaxConns() && waits > 0 {
+				return fmt.Errorf("%w: %d/%d in use, %d waits since last check",
+					pgutils.ErrPoolSaturated, st.AcquiredConns(), st.MaxConns(), waits)
+			}
+
+			return nil
+		},
+	}
+}
+
+// SchemaCheck verifies that golang-migrate's schema_migrations table is at
+// least at version want and not dirty.
+func SchemaCheck(pool *pgxpool.Pool, want uint) health.Check {
+	return health.Check{
+		Name: "postgres.schema",
+		Fn: func(ctx context.Context) error {
+			var (
+				version int64
+				dirty   bool
+			)
+
+			err := pool.QueryRow(ctx, `
+				SELECT version, dirty
+				FROM schema_migrations
+				LIMIT 1
+			`).Scan(&version, &dirty)
+			if err != nil {
+				return fmt.Errorf("read schema version: %w", err)
+			}
+
+			if dirty {
+				return fmt.Errorf("%w: version %d", pgutils.ErrSchemaDirty, version)
+			}
+
+			if version < int64(want) { //nolint:gosec // migration versions are small
+				return fmt.Errorf("%w: have %d, want %d", pgutils.ErrSchemaOutdated, version, want)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,79 @@
+// Package pgxutils is the native pgxpool counterpart of pgutils: opening the
+// pool, transactions and health checks without the database/sql layer.
+package pgxutils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OpenPool opens a pgxpool tuned from pgConfig. Queries use pgx's automatic
+// prepared-statement cache, and statement_timeout / lock_timeout are applied
+// to every session as in pgutils.OpenDB. The pool is closed when queue shuts
+// down.
+func OpenPool(ctx context.Context, pgConfig *config.PostgresConfig, queue *shutdownqueue.Queue) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(pgConfig.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("parse dsn: %w", err)
+	}
+
+	if pgConfig.MaxOpenConns > 0 {
+		poolConfig.MaxConns = clampInt32(pgConfig.MaxOpenConns)
+	}
+
+	poolConfig.MinConns = clampInt32(pgConfig.MinConns)
+
+	if pgConfig.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = pgConfig.ConnMaxIdleTime
+	}
+
+	if pgConfig.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = pgConfig.ConnMaxLifetime
+	}
+
+	if pgConfig.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = pgConfig.HealthCheckPeriod
+	}
+
+	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	if pgConfig.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = pgConfig.StatementCacheCapacity
+	}
+
+	pgutils.ApplySessionTimeouts(poolConfig.ConnConfig, pgConfig)
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("open pool: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	err = pool.Ping(pingCtx)
+	if err != nil {
+		pool.Close()
+
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+
+	queue.Add(func(context.Context) error {
+		pool.Close()
+
+		return nil
+	}, shutdownqueue.WithName("postgres pool "+poolConfig.ConnConfig.Host))
+
+	return pool, nil
+}
+
+func clampInt32(n int) int32 {
+	return int32(max(0, min(n, math.MaxInt32))) //nolint:gosec // clamped above
+}
@@ -0,0 +1,139 @@
+package pgxutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Querier is the subset of *pgxpool.Pool and pgx.Tx used by the repos.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+type txManager struct{ pool *pgxpool.Pool }
+
+const tracerScope = "github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+
+var (
+	_ txn.Manager = (*txManager)(nil)
+
+	ErrUnsupportedIsolation = errors.New("unsupported isolation level")
+)
+
+// NewTxManager returns a txn.Manager running transactions on pool.
+func NewTxManager(pool *pgxpool.Pool) *txManager {
+	return &txManager{pool: pool}
+}
+
+func (m *txManager) WithTx(ctx context.Context, fn func(context.Context) error, opts ...txn.Option) error {
+	return WithTx(ctx, m.pool, fn, opts...)
+}
+
+// ContextWithTx returns a copy of ctx carrying tx, so repo calls made with it
+// run inside tx.
+func ContextWithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Conn returns the transaction carried by ctx, or pool when there is none.
+//
+//nolint:ireturn
+func Conn(ctx context.Context, pool *pgxpool.Pool) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return pool
+}
+
+// WithTx runs fn inside a transaction, with the same semantics as
+// pgutils.WithTx: commit on nil, rollback otherwise, join an outer
+// transaction carried by ctx, and retry serialization failures and deadlocks
+// when asked to with txn.WithRetry.
+func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(context.Context) error, opts ...txn.Option) (retErr error) {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	o := txn.NewOptions(opts...)
+
+	ctx, span := tracing.Start(ctx, tracerScope, "pgxutils.WithTx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.transaction.isolation", o.Isolation.String())),
+	)
+	defer func() { tracing.End(span, retErr) }()
+
+	isoLevel, err := isoLevel(o.Isolation)
+	if err != nil {
+		return err
+	}
+
+	txOpts := pgx.TxOptions{IsoLevel: isoLevel}
+	if o.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	ctx, cancel := pgutils.Timeouts{Tx: o.Timeout}.TxContext(ctx)
+	defer cancel()
+
+	return pgutils.Retry(ctx, o.MaxAttempts, o.BaseBackoff, func(ctx context.Context) error {
+		return runTx(ctx, pool, fn, txOpts)
+	})
+}
+
+func runTx(ctx context.Context, pool *pgxpool.Pool, fn func(context.Context) error, txOpts pgx.TxOptions) error {
+	tx, err := pool.BeginTx(ctx, txOpts)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	err = fn(ContextWithTx(ctx, tx))
+	if err != nil {
+		// Use a fresh context so a cancelled request still releases the
+		// connection cleanly.
+		rbErr := tx.Rollback(context.WithoutCancel(ctx))
+		if rbErr != nil {
+			return fmt.Errorf("rollback after fn error: %v (fn err: %w)", rbErr, err)
+		}
+		return fmt.Errorf("fn: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func isoLevel(level sql.IsolationLevel) (pgx.TxIsoLevel, error) {
+	switch level {
+	case sql.LevelDefault:
+		return "", nil
+	case sql.LevelReadUncommitted:
+		return pgx.ReadUncommitted, nil
+	case sql.LevelReadCommitted:
+		return pgx.ReadCommitted, nil
+	case sql.LevelRepeatableRead:
+		return pgx.RepeatableRead, nil
+	case sql.LevelSerializable:
+		return pgx.Serializable, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedIsolation, level)
+	}
+}
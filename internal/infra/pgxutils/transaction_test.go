@@ -0,0 +1,90 @@
+package pgxutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsoLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		level   sql.IsolationLevel
+		want    pgx.TxIsoLevel
+		wantErr error
+	}{
+		{level: sql.LevelDefault, want: ""},
+		{level: sql.LevelReadCommitted, want: pgx.ReadCommitted},
+		{level: sql.LevelRepeatableRead, want: pgx.RepeatableRead},
+		{level: sql.LevelSerializable, want: pgx.Serializable},
+		{level: sql.LevelLinearizable, wantErr: ErrUnsupportedIsolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := isoLevel(tt.level)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Fatalf("isoLevel(%s) = %q, want %q", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTx_RetriesSerializationFailure(t *testing.T) {
+	t.Parallel()
+
+	pool, cleanup := pgtestutil.NewTestPool(t)
+	defer cleanup()
+
+	calls := 0
+
+	err := WithTx(t.Context(), pool, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+
+		_, err := Conn(ctx, pool).Exec(ctx, `INSERT INTO users (id, balance) VALUES (1, 0)`)
+
+		return err //nolint:wrapcheck
+	}, txn.WithIsolation(sql.LevelSerializable), txn.WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("fn called %d times, want 3", calls)
+	}
+}
+
+func TestWithTx_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	pool, cleanup := pgtestutil.NewTestPool(t)
+	defer cleanup()
+
+	err := WithTx(t.Context(), pool, func(ctx context.Context) error {
+		_, err := Conn(ctx, pool).Exec(ctx, `INSERT INTO users (id, balance) VALUES (1, 0)`)
+
+		return err //nolint:wrapcheck
+	}, txn.ReadOnly())
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "25006" { // read_only_sql_transaction
+		t.Fatalf("err = %v, want SQLSTATE 25006", err)
+	}
+}
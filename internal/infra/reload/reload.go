@@ -0,0 +1,142 @@
+// Package reload re-loads a running program's configuration on demand and
+// hands the fields that may change live to the subsystems that use them.
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+// Subscriber applies a reloaded config. changes lists the applied changes,
+// so subscribers can skip reloads that do not concern them; cfg must not be
+// modified.
+type Subscriber[T any] func(ctx context.Context, cfg *T, changes []envconf.Change) error
+
+// Reloader holds the current config of type T and replaces it on Reload.
+// Which fields may change is decided by their `reload:"true"` tags, see
+// envconf.Reload.
+type Reloader[T any] struct {
+	load    func() (*T, error)
+	current atomic.Pointer[T]
+
+	// mu serialises Reload and guards subs and frozen.
+	mu     sync.Mutex
+	subs   []subscription[T]
+	frozen []string
+}
+
+type subscription[T any] struct {
+	name string
+	fn   Subscriber[T]
+}
+
+// New returns a Reloader starting from cur that reloads with load.
+func New[T any](cur *T, load func() (*T, error)) *Reloader[T] {
+	r := &Reloader[T]{load: load}
+	r.current.Store(cur)
+
+	return r
+}
+
+// Current returns the config in use. It must not be modified.
+func (r *Reloader[T]) Current() *T {
+	return r.current.Load()
+}
+
+// Subscribe registers fn to be called, in registration order, after each
+// Reload that applies a change. name identifies the subsystem in logs.
+func (r *Reloader[T]) Subscribe(name string, fn Subscriber[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subs = append(r.subs, subscription[T]{name: name, fn: fn})
+}
+
+// Freeze makes the variables envs need a restart even though their fields are
+// tagged reload:"true", e.g. because the backend in use cannot apply them
+// live.
+func (r *Reloader[T]) Freeze(envs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.frozen = append(r.frozen, envs...)
+}
+
+// Reload loads the config again. If it fails to load or validate, the
+// current config stays in use. Otherwise changes to fields that cannot be
+// reloaded are logged and ignored, the rest become current and every
+// subscriber is notified; their errors are joined. Reload returns all
+// changes, applied or not.
+func (r *Reloader[T]) Reload(ctx context.Context) ([]envconf.Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	changes, err := envconf.Reload(r.current.Load(), next, r.frozen...)
+	if err != nil {
+		return nil, fmt.Errorf("compare config: %w", err)
+	}
+
+	var applied []envconf.Change
+
+	for _, c := range changes {
+		if !c.Applied {
+			slog.WarnContext(ctx, "Config change needs a restart; ignored",
+				"env", c.Env, "current", c.Old, "requested", c.New)
+
+			continue
+		}
+
+		slog.InfoContext(ctx, "Config change applied", "env", c.Env, "old", c.Old, "new", c.New)
+
+		applied = append(applied, c)
+	}
+
+	if len(applied) == 0 {
+		return changes, nil
+	}
+
+	r.current.Store(next)
+
+	var errs []error
+
+	for _, s := range r.subs {
+		err := s.fn(ctx, next, applied)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// Watch calls Reload for every signal received on sigs until ctx is done.
+// Failures are logged; the previous config stays in use. The caller owns the
+// signal.Notify registration: keep it for the life of the process, so that
+// signals arriving before Watch starts or after it returns are not fatal.
+func (r *Reloader[T]) Watch(ctx context.Context, sigs <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigs:
+			slog.InfoContext(ctx, "Reloading config", "signal", sig.String())
+
+			_, err := r.Reload(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Config reload failed", "error", err)
+			}
+		}
+	}
+}
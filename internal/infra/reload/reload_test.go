@@ -0,0 +1,164 @@
+package reload_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/reload"
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+type testConfig struct {
+	Port  int    `env:"PORT"`
+	Level string `env:"LEVEL" reload:"true"`
+}
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	var (
+		next    testConfig
+		loadErr error
+	)
+
+	r := reload.New(&testConfig{Port: 80, Level: "INFO"}, func() (*testConfig, error) {
+		cfg := next
+
+		return &cfg, loadErr
+	})
+
+	var got []string
+
+	r.Subscribe("first", func(_ context.Context, cfg *testConfig, changes []envconf.Change) error {
+		got = append(got, "first "+cfg.Level)
+
+		if len(changes) != 1 || changes[0].Env != "LEVEL" {
+			t.Errorf("changes = %+v, want only LEVEL", changes)
+		}
+
+		return nil
+	})
+	r.Subscribe("second", func(_ context.Context, cfg *testConfig, _ []envconf.Change) error {
+		got = append(got, "second "+cfg.Level)
+
+		return errors.New("boom")
+	})
+
+	// Port cannot change live; Level can.
+	next = testConfig{Port: 81, Level: "DEBUG"}
+
+	changes, err := r.Reload(t.Context())
+	if err == nil || err.Error() != "second: boom" {
+		t.Errorf("Reload error = %v, want second: boom", err)
+	}
+
+	if len(changes) != 2 || changes[0].Applied || !changes[1].Applied {
+		t.Errorf("changes = %+v, want PORT ignored and LEVEL applied", changes)
+	}
+
+	if want := []string{"first DEBUG", "second DEBUG"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subscribers saw %q, want %q", got, want)
+	}
+
+	if cur := *r.Current(); cur != (testConfig{Port: 80, Level: "DEBUG"}) {
+		t.Errorf("Current = %+v, want port kept and level reloaded", cur)
+	}
+
+	// Nothing reloadable changed: subscribers are not called.
+	got = nil
+	next = testConfig{Port: 82, Level: "DEBUG"}
+
+	_, err = r.Reload(t.Context())
+	if err != nil {
+		t.Errorf("Reload: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("subscribers called for an ignored change: %q", got)
+	}
+
+	// A config that fails to load leaves the current one in use.
+	loadErr = errors.New("invalid")
+	next = testConfig{Port: 80, Level: "WARN"}
+
+	_, err = r.Reload(t.Context())
+	if err == nil {
+		t.Error("Reload: want load error")
+	}
+
+	if cur := r.Current().Level; cur != "DEBUG" {
+		t.Errorf("Level after failed reload = %q, want DEBUG", cur)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	loaded := make(chan struct{}, 1)
+
+	r := reload.New(&testConfig{Level: "INFO"}, func() (*testConfig, error) {
+		loaded <- struct{}{}
+
+		return &testConfig{Level: "DEBUG"}, nil
+	})
+
+	sigs := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		r.Watch(ctx, sigs)
+	}()
+
+	sigs <- syscall.SIGHUP
+
+	select {
+	case <-loaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal did not trigger a reload")
+	}
+
+	cancel()
+	<-done
+
+	if got := r.Current().Level; got != "DEBUG" {
+		t.Errorf("Level = %q, want DEBUG", got)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	t.Parallel()
+
+	r := reload.New(&testConfig{Level: "INFO"}, func() (*testConfig, error) {
+		return &testConfig{Level: "DEBUG"}, nil
+	})
+	r.Freeze("LEVEL")
+
+	called := false
+
+	r.Subscribe("sub", func(context.Context, *testConfig, []envconf.Change) error {
+		called = true
+
+		return nil
+	})
+
+	changes, err := r.Reload(t.Context())
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Applied {
+		t.Errorf("changes = %+v, want LEVEL not applied", changes)
+	}
+
+	if got := r.Current().Level; got != "INFO" || called {
+		t.Errorf("Level = %q, subscriber called = %v; want INFO and no call", got, called)
+	}
+}
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// otlpMetricsPath is where OTLP/HTTP collectors accept metrics.
+const otlpMetricsPath = "/v1/metrics"
+
+// setupMetrics installs a MeterProvider that periodically exports to kind as
+// the global provider, so instruments such as the cache counters are
+// recorded. The interval is the SDK default (60s, see
+// OTEL_METRIC_EXPORT_INTERVAL). The provider is flushed and shut down when
+// queue shuts down.
+func setupMetrics(
+	ctx context.Context,
+	queue *shutdownqueue.Queue,
+	kind, otlpEndpoint string,
+	res *resource.Resource,
+) error {
+	exp, err := newMetricExporter(ctx, kind, otlpEndpoint)
+	if err != nil {
+		return fmt.Errorf("create metric exporter: %w", err)
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+
+	queue.Add(func(ctx context.Context) error {
+		err := mp.Shutdown(ctx)
+		if err != nil {
+			return fmt.Errorf("shutdown meter provider: %w", err)
+		}
+
+		return nil
+	}, shutdownqueue.WithName("meter provider"))
+
+	return nil
+}
+
+//nolint:ireturn
+func newMetricExporter(ctx context.Context, kind, otlpEndpoint string) (sdkmetric.Exporter, error) {
+	switch kind {
+	case ExporterStdout:
+		exp, err := stdoutmetric.New()
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+
+		return exp, nil
+	case ExporterOTLP:
+		var opts []otlpmetrichttp.Option
+		if otlpEndpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(metricsURL(otlpEndpoint)))
+		}
+
+		exp, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+
+		return exp, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, kind)
+	}
+}
+
+// metricsURL points a bare collector URL such as http://collector:4318 at its
+// metrics path; a URL with a path is used as is.
+func metricsURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || strings.Trim(u.Path, "/") != "" {
+		return endpoint
+	}
+
+	u.Path = otlpMetricsPath
+
+	return u.String()
+}
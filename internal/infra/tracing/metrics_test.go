@@ -0,0 +1,26 @@
+package tracing
+
+import "testing"
+
+func TestMetricsURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "http://collector:4318", want: "http://collector:4318/v1/metrics"},
+		{endpoint: "http://collector:4318/", want: "http://collector:4318/v1/metrics"},
+		{endpoint: "https://collector/custom/metrics", want: "https://collector/custom/metrics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			t.Parallel()
+
+			if got := metricsURL(tt.endpoint); got != tt.want {
+				t.Errorf("metricsURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package tracing wires OpenTelemetry for the service: it installs the global
+// TracerProvider, MeterProvider and W3C propagators, and offers small helpers
+// used by the HTTP, service and repository layers to start and finish spans.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/pkg/shutdownqueue"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported values of config.TracingConfig.Exporter and MetricsExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Setup installs the W3C trace-context propagator and, unless their exporter
+// is "none", a batching TracerProvider and a periodically exporting
+// MeterProvider as the global providers. The providers are flushed and shut
+// down when queue shuts down.
+func Setup(ctx context.Context, cfg *config.TracingConfig, queue *shutdownqueue.Queue) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))
+
+	metrics := strings.ToLower(strings.TrimSpace(cfg.MetricsExporter))
+	if metrics != ExporterNone && metrics != "" {
+		err := setupMetrics(ctx, queue, metrics, cfg.OTLPEndpoint, res)
+		if err != nil {
+			return err
+		}
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(cfg.Exporter))
+	if kind == ExporterNone || kind == "" {
+		return nil
+	}
+
+	exp, err := newExporter(ctx, kind, cfg.OTLPEndpoint)
+	if err != nil {
+		return fmt.Errorf("create exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	queue.Add(func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if err != nil {
+			return fmt.Errorf("shutdown tracer provider: %w", err)
+		}
+
+		return nil
+	}, shutdownqueue.WithName("tracer provider"))
+
+	return nil
+}
+
+//nolint:ireturn
+func newExporter(ctx context.Context, kind, otlpEndpoint string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case ExporterStdout:
+		exp, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+
+		return exp, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if otlpEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(otlpEndpoint))
+		}
+
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+
+		return exp, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, kind)
+	}
+}
+
+// Start starts a span named name using the tracer of the given
+// instrumentation scope (usually the import path of the calling package).
+//
+//nolint:ireturn
+func Start(
+	ctx context.Context,
+	scope, name string,
+	opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	return otel.Tracer(scope).Start(ctx, name, opts...)
+}
+
+// End records err on span (if any) and ends it. Intended to be deferred
+// with a named error result:
+//
+//	ctx, span := tracing.Start(ctx, scope, "op")
+//	defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,17 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// Ledger applies a balance change and records its transaction ID atomically,
+// in a single round trip where the backend supports it.
+//
+// Apply returns the user's new balance. It reports apperr.ErrDuplicateTransaction,
+// apperr.ErrUserNotFound and apperr.ErrInsufficientFunds without changing any
+// state.
+type Ledger interface {
+	Apply(ctx context.Context, txid string, userID uint64, delta money.Amount) (money.Amount, error)
+}
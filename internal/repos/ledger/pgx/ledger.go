@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/ledger"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ ledger.Ledger = (*ledgerRepo)(nil)
+
+// Outcomes returned by apply_balance_transaction (migration 000003).
+const (
+	statusApplied           = "applied"
+	statusDuplicate         = "duplicate"
+	statusUserNotFound      = "user_not_found"
+	statusInsufficientFunds = "insufficient_funds"
+)
+
+type ledgerRepo struct {
+	pool *pgxpool.Pool
+	opts pgutils.RepoOptions
+}
+
+func New(pool *pgxpool.Pool, opts ...pgutils.RepoOption) *ledgerRepo {
+	return &ledgerRepo{pool: pool, opts: pgutils.NewRepoOptions(opts...)}
+}
+
+// Apply runs apply_balance_transaction as a single autocommit statement.
+func (r *ledgerRepo) Apply(
+	ctx context.Context,
+	txid string,
+	userID uint64,
+	delta money.Amount,
+) (_ money.Amount, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "ledger.Apply")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var (
+		status  string
+		balance sql.Null[money.Amount]
+	)
+
+	err = r.pool.QueryRow(ctx, `
+		SELECT out_status, new_balance
+		FROM apply_balance_transaction($1, $2, $3)
+	`, txid, userID, delta).Scan(&status, &balance)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22003" { // numeric_value_out_of_range
+			return 0, apperr.Invalid(apperr.CodeInvalidAmount, "amount", "balance would overflow")
+		}
+
+		return 0, fmt.Errorf("apply transaction: %w", err)
+	}
+
+	switch status {
+	case statusApplied:
+		return balance.V, nil
+	case statusDuplicate:
+		return 0, apperr.ErrDuplicateTransaction
+	case statusUserNotFound:
+		return 0, apperr.ErrUserNotFound
+	case statusInsufficientFunds:
+		return 0, apperr.ErrInsufficientFunds
+	default:
+		return 0, fmt.Errorf("apply transaction: unexpected status %q", status)
+	}
+}
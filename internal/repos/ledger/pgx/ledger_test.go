@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestLedger_Pgx_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		txid        string
+		userID      uint64
+		delta       money.Amount
+		wantErr     error
+		wantBalance money.Amount // balance of user 1 afterwards
+	}{
+		{name: "win_applied", txid: "tx-win", userID: 1, delta: 250, wantBalance: 350},
+		{name: "lose_to_zero", txid: "tx-lose", userID: 1, delta: -100, wantBalance: 0},
+		{name: "insufficient_funds", txid: "tx-big", userID: 1, delta: -101, wantErr: apperr.ErrInsufficientFunds, wantBalance: 100},
+		{name: "duplicate", txid: "tx-seen", userID: 1, delta: 50, wantErr: apperr.ErrDuplicateTransaction, wantBalance: 100},
+		{name: "user_not_found", txid: "tx-ghost", userID: 2, delta: 50, wantErr: apperr.ErrUserNotFound, wantBalance: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, cleanup := pgtestutil.NewTestPool(t)
+			defer cleanup()
+
+			pgtestutil.SeedPool(t, pool, pgtestutil.Fixture{
+				Users:        []pgtestutil.User{{ID: 1, Balance: 100}},
+				Transactions: []pgtestutil.Transaction{{ID: "tx-seen", UserID: 1}},
+			})
+
+			got, err := New(pool).Apply(t.Context(), tt.txid, tt.userID, tt.delta)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v, got %v", tt.wantErr, err)
+			}
+
+			if tt.wantErr == nil && got != tt.wantBalance {
+				t.Fatalf("returned balance: want %s, got %s", tt.wantBalance, got)
+			}
+
+			var bal money.Amount
+
+			err = pool.QueryRow(t.Context(), `SELECT balance FROM users WHERE id = 1`).Scan(&bal)
+			if err != nil {
+				t.Fatalf("read balance: %v", err)
+			}
+
+			if bal != tt.wantBalance {
+				t.Fatalf("stored balance: want %s, got %s", tt.wantBalance, bal)
+			}
+		})
+	}
+}
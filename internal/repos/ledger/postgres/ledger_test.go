@@ -0,0 +1,183 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestLedger_Apply_Table(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		seed        pgtestutil.Fixture
+		txid        string
+		userID      uint64
+		delta       money.Amount
+		wantErr     error
+		wantBalance money.Amount // final balance read back from users
+		wantTxRow   bool         // whether txid must be recorded afterwards
+	}{
+		{
+			name:        "win_applied",
+			seed:        pgtestutil.Fixture{Users: []pgtestutil.User{{ID: 1, Balance: 100}}},
+			txid:        "tx-win",
+			userID:      1,
+			delta:       250,
+			wantBalance: 350,
+			wantTxRow:   true,
+		},
+		{
+			name:        "lose_to_zero",
+			seed:        pgtestutil.Fixture{Users: []pgtestutil.User{{ID: 1, Balance: 300}}},
+			txid:        "tx-lose",
+			userID:      1,
+			delta:       -300,
+			wantBalance: 0,
+			wantTxRow:   true,
+		},
+		{
+			name:        "insufficient_funds_releases_claim",
+			seed:        pgtestutil.Fixture{Users: []pgtestutil.User{{ID: 1, Balance: 200}}},
+			txid:        "tx-short",
+			userID:      1,
+			delta:       -300,
+			wantErr:     apperr.ErrInsufficientFunds,
+			wantBalance: 200,
+			wantTxRow:   false,
+		},
+		{
+			name: "duplicate_does_not_touch_balance",
+			seed: pgtestutil.Fixture{
+				Users:        []pgtestutil.User{{ID: 1, Balance: 500}},
+				Transactions: []pgtestutil.Transaction{{ID: "tx-dup", UserID: 1}},
+			},
+			txid:        "tx-dup",
+			userID:      1,
+			delta:       100,
+			wantErr:     apperr.ErrDuplicateTransaction,
+			wantBalance: 500,
+			wantTxRow:   true,
+		},
+		{
+			name:    "user_not_found",
+			txid:    "tx-ghost",
+			userID:  999,
+			delta:   100,
+			wantErr: apperr.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, cleanup := pgtestutil.NewTestDB(t)
+			defer cleanup()
+
+			pgtestutil.Seed(t, db, tt.seed)
+
+			repo := New(db)
+
+			got, err := repo.Apply(t.Context(), tt.txid, tt.userID, tt.delta)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v, got %v", tt.wantErr, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("apply: %v", err)
+				}
+
+				if got != tt.wantBalance {
+					t.Fatalf("returned balance: want %s, got %s", tt.wantBalance, got)
+				}
+			}
+
+			if errors.Is(tt.wantErr, apperr.ErrUserNotFound) {
+				return
+			}
+
+			var bal money.Amount
+
+			err = db.QueryRow(`SELECT balance FROM users WHERE id = $1`, tt.userID).Scan(&bal)
+			if err != nil {
+				t.Fatalf("read balance: %v", err)
+			}
+
+			if bal != tt.wantBalance {
+				t.Fatalf("stored balance: want %s, got %s", tt.wantBalance, bal)
+			}
+
+			var recorded, claimed bool
+
+			err = db.QueryRow(`
+				SELECT EXISTS(SELECT 1 FROM transactions WHERE transaction_id = $1),
+				       EXISTS(SELECT 1 FROM transaction_keys WHERE transaction_id = $1)
+			`, tt.txid).Scan(&recorded, &claimed)
+			if err != nil {
+				t.Fatalf("read tx row: %v", err)
+			}
+
+			if recorded != tt.wantTxRow || claimed != tt.wantTxRow {
+				t.Fatalf("tx row recorded: want %v, got row %v key %v", tt.wantTxRow, recorded, claimed)
+			}
+		})
+	}
+}
+
+func TestLedger_Apply_ConcurrentLosesNeverNegative(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	_, err := db.Exec(`INSERT INTO users (id, balance) VALUES ($1, $2)`, 1, 1_000)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+
+	repo := New(db)
+
+	const workers = 20 // each tries to take 1.00 out of 10.00
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		ok, rejected int
+	)
+
+	for i := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := repo.Apply(t.Context(), fmt.Sprintf("tx-%d", i), 1, -100)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case err == nil:
+				ok++
+			case errors.Is(err, apperr.ErrInsufficientFunds):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if ok != 10 || rejected != workers-10 {
+		t.Fatalf("want 10 applied and %d rejected, got %d/%d", workers-10, ok, rejected)
+	}
+}
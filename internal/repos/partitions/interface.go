@@ -0,0 +1,24 @@
+package partitions
+
+import (
+	"context"
+	"time"
+)
+
+// Partitions manages the monthly partitions of the transactions table and
+// the retention of transaction_keys.
+// Calls are meant to run inside one transaction (see txn.Manager).
+type Partitions interface {
+	// Lock serializes maintenance runs until the surrounding transaction ends.
+	Lock(ctx context.Context) error
+	// Create adds the missing monthly partitions covering [from, to] and
+	// returns their names.
+	Create(ctx context.Context, from, to time.Time) ([]string, error)
+	// Archive detaches the partitions that end at or before before, moves
+	// them out of the live schema and returns their names. Their transaction
+	// ids stay reserved.
+	Archive(ctx context.Context, before time.Time) ([]string, error)
+	// PruneKeys deletes the transaction ids claimed before before and returns
+	// how many it deleted. Those ids can then be applied again.
+	PruneKeys(ctx context.Context, before time.Time) (int64, error)
+}
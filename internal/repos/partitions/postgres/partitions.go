@@ -0,0 +1,120 @@
+package partitions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/partitions"
+)
+
+var _ partitions.Partitions = (*partitionsRepo)(nil)
+
+// partitionsRepo calls the partition functions from migration 000005.
+type partitionsRepo struct {
+	db   *sql.DB
+	opts pgutils.RepoOptions
+}
+
+func New(db *sql.DB, opts ...pgutils.RepoOption) *partitionsRepo {
+	return &partitionsRepo{db: db, opts: pgutils.NewRepoOptions(opts...)}
+}
+
+func (r *partitionsRepo) Lock(ctx context.Context) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "partitions.Lock")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	_, err = pgutils.Conn(ctx, r.db).ExecContext(ctx, `
+		SELECT pg_advisory_xact_lock(hashtext('transactions_partitions'))
+	`)
+	if err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+
+	return nil
+}
+
+func (r *partitionsRepo) Create(ctx context.Context, from, to time.Time) (_ []string, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "partitions.Create")
+	defer func() { tracing.End(span, err) }()
+
+	names, err := r.names(ctx, `SELECT create_transaction_partitions($1, $2)`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("create partitions: %w", err)
+	}
+
+	return names, nil
+}
+
+func (r *partitionsRepo) Archive(ctx context.Context, before time.Time) (_ []string, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "partitions.Archive")
+	defer func() { tracing.End(span, err) }()
+
+	names, err := r.names(ctx, `SELECT archive_transaction_partitions($1)`, before)
+	if err != nil {
+		return nil, fmt.Errorf("archive partitions: %w", err)
+	}
+
+	return names, nil
+}
+
+func (r *partitionsRepo) PruneKeys(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "partitions.PruneKeys")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	res, err := pgutils.Conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM transaction_keys WHERE created_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune transaction keys: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("prune transaction keys: %w", err)
+	}
+
+	return n, nil
+}
+
+// names runs a set-returning partition function and collects its rows.
+func (r *partitionsRepo) names(ctx context.Context, query string, args ...any) ([]string, error) {
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	rows, err := pgutils.Conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	//nolint:errcheck
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+
+		names = append(names, name)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,138 @@
+package partitions
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+)
+
+func TestPartitions_CreateAndArchive(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	repo := New(db)
+	ctx := t.Context()
+
+	from := time.Date(2031, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+	created, err := repo.Create(ctx, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	want := []string{"transactions_2031_10", "transactions_2031_11"}
+	if !slices.Equal(created, want) {
+		t.Fatalf("created: want %v, got %v", want, created)
+	}
+
+	created, err = repo.Create(ctx, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("create again: %v", err)
+	}
+
+	if len(created) != 0 {
+		t.Fatalf("second create must be a no-op, got %v", created)
+	}
+
+	// A transaction recorded in the current month's partition.
+	_, err = db.Exec(`
+		INSERT INTO users (id, balance) VALUES (1, 0);
+		INSERT INTO transaction_keys (transaction_id) VALUES ('tx-old');
+		INSERT INTO transactions (transaction_id, user_id) VALUES ('tx-old', 1);
+	`)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	current := "transactions_" + time.Now().UTC().Format("2006_01")
+
+	archived, err := repo.Archive(ctx, time.Now().AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+
+	if !slices.Contains(archived, current) {
+		t.Fatalf("archived %v, want it to include %s", archived, current)
+	}
+
+	if slices.Contains(archived, "transactions_2031_10") {
+		t.Fatalf("future partition archived: %v", archived)
+	}
+
+	var inArchive bool
+
+	err = db.QueryRow(`SELECT to_regclass('archive.' || $1) IS NOT NULL`, current).Scan(&inArchive)
+	if err != nil {
+		t.Fatalf("look up archived table: %v", err)
+	}
+
+	if !inArchive {
+		t.Fatalf("%s not moved to the archive schema", current)
+	}
+
+	// The id stays reserved after its record left the live table.
+	_, err = db.Exec(`INSERT INTO transaction_keys (transaction_id) VALUES ('tx-old')`)
+	if err == nil {
+		t.Fatal("archived transaction id must still be rejected as a duplicate")
+	}
+}
+
+func TestPartitions_PruneKeys(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	repo := New(db)
+	now := time.Now()
+
+	_, err := db.Exec(`
+		INSERT INTO transaction_keys (transaction_id, created_at) VALUES
+			('tx-expired', $1), ('tx-kept', $2)
+	`, now.AddDate(-2, 0, 0), now.AddDate(0, -1, 0))
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	pruned, err := repo.PruneKeys(t.Context(), now.AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	if pruned != 1 {
+		t.Fatalf("pruned %d keys, want 1", pruned)
+	}
+
+	var left []string
+
+	rows, err := db.Query(`SELECT transaction_id FROM transaction_keys ORDER BY transaction_id`)
+	if err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	//nolint:errcheck
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+
+		err = rows.Scan(&id)
+		if err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+
+		left = append(left, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+
+	if !slices.Equal(left, []string{"tx-kept"}) {
+		t.Fatalf("keys left: %v, want [tx-kept]", left)
+	}
+}
@@ -0,0 +1,13 @@
+package replication
+
+import "context"
+
+// Tracker hands out write tokens on the primary and checks them on replicas,
+// so a client that just wrote can be guaranteed to read its own writes.
+type Tracker interface {
+	// WriteToken returns an opaque token covering every write committed so far.
+	WriteToken(ctx context.Context) (string, error)
+	// CaughtUp reports whether the store has applied every write covered by
+	// token. A store that is not replaying from a primary is always caught up.
+	CaughtUp(ctx context.Context, token string) (bool, error)
+}
@@ -0,0 +1,60 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/replication"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ replication.Tracker = (*trackerRepo)(nil)
+
+// trackerRepo uses WAL positions (LSNs) as write tokens.
+type trackerRepo struct {
+	pool *pgxpool.Pool
+	opts pgutils.RepoOptions
+}
+
+func New(pool *pgxpool.Pool, opts ...pgutils.RepoOption) *trackerRepo {
+	return &trackerRepo{pool: pool, opts: pgutils.NewRepoOptions(opts...)}
+}
+
+func (r *trackerRepo) WriteToken(ctx context.Context) (_ string, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "replication.WriteToken")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var lsn string
+
+	err = r.pool.QueryRow(ctx, `SELECT pg_current_wal_lsn()::text`).Scan(&lsn)
+	if err != nil {
+		return "", fmt.Errorf("current wal lsn: %w", err)
+	}
+
+	return lsn, nil
+}
+
+func (r *trackerRepo) CaughtUp(ctx context.Context, token string) (_ bool, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "replication.CaughtUp")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var caughtUp bool
+
+	// pg_last_wal_replay_lsn is NULL when the server is not in recovery.
+	err = r.pool.QueryRow(ctx, `
+		SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, true)
+	`, token).Scan(&caughtUp)
+	if err != nil {
+		return false, fmt.Errorf("compare replay lsn: %w", err)
+	}
+
+	return caughtUp, nil
+}
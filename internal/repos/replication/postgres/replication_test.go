@@ -0,0 +1,41 @@
+package replication
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+)
+
+// On a primary every token is caught up: there is nothing to replay.
+func TestTracker_Primary(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	repo := New(db)
+
+	token, err := repo.WriteToken(t.Context())
+	if err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	if !regexp.MustCompile(`^[0-9A-F]+/[0-9A-F]+$`).MatchString(token) {
+		t.Fatalf("token %q is not an LSN", token)
+	}
+
+	caughtUp, err := repo.CaughtUp(t.Context(), token)
+	if err != nil {
+		t.Fatalf("caught up: %v", err)
+	}
+
+	if !caughtUp {
+		t.Fatal("primary must always be caught up")
+	}
+
+	_, err = repo.CaughtUp(t.Context(), "not-an-lsn")
+	if err == nil {
+		t.Fatal("malformed token must fail")
+	}
+}
@@ -0,0 +1,44 @@
+// Package repotest is the conformance suite for the storage backends: every
+// implementation of users.Users must pass RunUsers and every implementation
+// of transactions.Transactions RunTransactions.
+//
+// Wiring a backend is one line in a test file of its package:
+//
+//	func TestContract(t *testing.T) { repotest.RunUsers(t, repotest.Postgres) }
+//
+// Memory, Postgres and Pgx build the Store of each backend; a new backend
+// adds its own NewStore next to them.
+package repotest
+
+import (
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/repos/transactions"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// Store is one backend instance under test.
+type Store struct {
+	TxManager    txn.Manager
+	Users        users.Users
+	Transactions transactions.Transactions
+	// SeedUser creates userID with balance, outside any transaction.
+	SeedUser func(t *testing.T, userID uint64, balance money.Amount)
+}
+
+// NewStore returns a fresh, empty Store; it registers its own cleanup on t.
+type NewStore func(t *testing.T) Store
+
+// RunUsers runs the users.Users suite against newStore.
+func RunUsers(t *testing.T, newStore NewStore) {
+	t.Helper()
+	runUsers(t, newStore)
+}
+
+// RunTransactions runs the transactions.Transactions suite against newStore.
+func RunTransactions(t *testing.T, newStore NewStore) {
+	t.Helper()
+	runTransactions(t, newStore)
+}
@@ -0,0 +1,69 @@
+package repotest
+
+import (
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	memtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/memory"
+	pgxtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/pgx"
+	pgtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/postgres"
+	memusers "github.com/fastprodman/EntainHW/internal/repos/users/memory"
+	pgxusers "github.com/fastprodman/EntainHW/internal/repos/users/pgx"
+	pgusers "github.com/fastprodman/EntainHW/internal/repos/users/postgres"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// Memory is a NewStore over the in-memory repos.
+func Memory(t *testing.T) Store {
+	t.Helper()
+
+	users := memusers.New()
+
+	return Store{
+		TxManager:    memtx.NewTxManager(),
+		Users:        users,
+		Transactions: memtransactions.New(users),
+		SeedUser: func(_ *testing.T, userID uint64, balance money.Amount) {
+			users.Put(userID, balance)
+		},
+	}
+}
+
+// Postgres is a NewStore over the database/sql repos, each store on its own
+// test database (see pgtestutil.NewTestDB).
+func Postgres(t *testing.T) Store {
+	t.Helper()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	t.Cleanup(cleanup)
+
+	return Store{
+		TxManager:    pgutils.NewTxManager(db),
+		Users:        pgusers.New(db),
+		Transactions: pgtransactions.New(db),
+		SeedUser: func(t *testing.T, userID uint64, balance money.Amount) {
+			pgtestutil.Seed(t, db, pgtestutil.Fixture{Users: []pgtestutil.User{{ID: userID, Balance: balance}}})
+		},
+	}
+}
+
+// Pgx is a NewStore over the native pgxpool repos, each store on its own test
+// database (see pgtestutil.NewTestPool).
+func Pgx(t *testing.T) Store {
+	t.Helper()
+
+	pool, cleanup := pgtestutil.NewTestPool(t)
+	t.Cleanup(cleanup)
+
+	return Store{
+		TxManager:    pgxutils.NewTxManager(pool),
+		Users:        pgxusers.New(pool),
+		Transactions: pgxtransactions.New(pool),
+		SeedUser: func(t *testing.T, userID uint64, balance money.Amount) {
+			pgtestutil.SeedPool(t, pool, pgtestutil.Fixture{Users: []pgtestutil.User{{ID: userID, Balance: balance}}})
+		},
+	}
+}
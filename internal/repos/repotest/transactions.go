@@ -0,0 +1,105 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+)
+
+func runTransactions(t *testing.T, newStore NewStore) {
+	t.Helper()
+
+	t.Run("insert_and_duplicate", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 0)
+
+		if err := s.Transactions.Insert(t.Context(), "tx-1", 1); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+
+		if err := s.Transactions.Insert(t.Context(), "tx-1", 1); !errors.Is(err, apperr.ErrDuplicateTransaction) {
+			t.Fatalf("duplicate: want ErrDuplicateTransaction, got %v", err)
+		}
+	})
+
+	t.Run("missing_user", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+
+		err := s.Transactions.Insert(t.Context(), "tx-1", 9)
+		if err == nil || errors.Is(err, apperr.ErrDuplicateTransaction) {
+			t.Fatalf("want a missing-user error, got %v", err)
+		}
+	})
+
+	t.Run("rollback_frees_id", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 0)
+
+		errBoom := errors.New("boom")
+
+		err := s.TxManager.WithTx(t.Context(), func(ctx context.Context) error {
+			err := s.Transactions.Insert(ctx, "tx-1", 1)
+			if err != nil {
+				return err
+			}
+
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("want %v, got %v", errBoom, err)
+		}
+
+		if err := s.Transactions.Insert(t.Context(), "tx-1", 1); err != nil {
+			t.Fatalf("insert after rollback: %v", err)
+		}
+	})
+
+	t.Run("concurrent_duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 0)
+
+		const workers = 20
+
+		var (
+			wg       sync.WaitGroup
+			inserted atomic.Int64
+		)
+
+		for range workers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := s.TxManager.WithTx(t.Context(), func(ctx context.Context) error {
+					return s.Transactions.Insert(ctx, "tx-1", 1)
+				})
+
+				switch {
+				case err == nil:
+					inserted.Add(1)
+				case !errors.Is(err, apperr.ErrDuplicateTransaction):
+					t.Errorf("insert: %v", err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if got := inserted.Load(); got != 1 {
+			t.Fatalf("inserted: want exactly 1, got %d", got)
+		}
+	})
+}
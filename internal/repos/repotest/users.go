@@ -0,0 +1,233 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func runUsers(t *testing.T, newStore NewStore) {
+	t.Helper()
+
+	t.Run("exists", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 0)
+
+		if err := s.Users.Exists(t.Context(), 1); err != nil {
+			t.Fatalf("existing user: %v", err)
+		}
+
+		if err := s.Users.Exists(t.Context(), 2); !errors.Is(err, apperr.ErrUserNotFound) {
+			t.Fatalf("missing user: want ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("get_balance", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 1234)
+
+		wantBalance(t, s, 1, 1234)
+
+		if _, err := s.Users.GetBalance(t.Context(), 2); !errors.Is(err, apperr.ErrUserNotFound) {
+			t.Fatalf("missing user: want ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("lock_and_get_balance", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 500)
+
+		err := s.TxManager.WithTx(t.Context(), func(ctx context.Context) error {
+			got, err := s.Users.LockAndGetBalance(ctx, 1)
+			if err != nil {
+				return err
+			}
+
+			if got != 500 {
+				t.Errorf("locked balance: want 500, got %s", got)
+			}
+
+			if _, err := s.Users.LockAndGetBalance(ctx, 2); err == nil {
+				t.Error("locking a missing user must fail")
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("with tx: %v", err)
+		}
+	})
+
+	t.Run("increase_decrease", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			start   money.Amount
+			apply   func(ctx context.Context, s Store) error
+			wantErr error
+			want    money.Amount
+		}{
+			{
+				name:  "increase",
+				start: 100,
+				apply: func(ctx context.Context, s Store) error { return s.Users.IncreaseBalance(ctx, 1, 50) },
+				want:  150,
+			},
+			{
+				name:  "decrease",
+				start: 100,
+				apply: func(ctx context.Context, s Store) error { return s.Users.DecreaseBalance(ctx, 1, 30) },
+				want:  70,
+			},
+			{
+				name:  "decrease_to_zero",
+				start: 100,
+				apply: func(ctx context.Context, s Store) error { return s.Users.DecreaseBalance(ctx, 1, 100) },
+				want:  0,
+			},
+			{
+				name:    "insufficient_funds",
+				start:   100,
+				apply:   func(ctx context.Context, s Store) error { return s.Users.DecreaseBalance(ctx, 1, 101) },
+				wantErr: apperr.ErrInsufficientFunds,
+				want:    100,
+			},
+			{
+				name:  "increase_missing_user_is_noop",
+				start: 100,
+				apply: func(ctx context.Context, s Store) error { return s.Users.IncreaseBalance(ctx, 2, 50) },
+				want:  100,
+			},
+			{
+				name:    "decrease_missing_user",
+				start:   100,
+				apply:   func(ctx context.Context, s Store) error { return s.Users.DecreaseBalance(ctx, 2, 1) },
+				wantErr: apperr.ErrInsufficientFunds,
+				want:    100,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				s := newStore(t)
+				s.SeedUser(t, 1, tt.start)
+
+				err := tt.apply(t.Context(), s)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v, got %v", tt.wantErr, err)
+				}
+
+				wantBalance(t, s, 1, tt.want)
+
+				// Nothing creates the missing user.
+				if err := s.Users.Exists(t.Context(), 2); !errors.Is(err, apperr.ErrUserNotFound) {
+					t.Fatalf("user 2: want ErrUserNotFound, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("rollback_discards_writes", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 100)
+
+		errBoom := errors.New("boom")
+
+		err := s.TxManager.WithTx(t.Context(), func(ctx context.Context) error {
+			err := s.Users.DecreaseBalance(ctx, 1, 40)
+			if err != nil {
+				return err
+			}
+
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("want %v, got %v", errBoom, err)
+		}
+
+		wantBalance(t, s, 1, 100)
+	})
+
+	t.Run("concurrent_decrements_never_negative", func(t *testing.T) {
+		t.Parallel()
+
+		s := newStore(t)
+		s.SeedUser(t, 1, 1000)
+
+		const workers = 30
+
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int64
+		)
+
+		for i := range workers {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				// Half go through lock-check-decrease like the service,
+				// half rely on the guarded decrement alone.
+				err := s.TxManager.WithTx(t.Context(), func(ctx context.Context) error {
+					if i%2 == 0 {
+						balance, err := s.Users.LockAndGetBalance(ctx, 1)
+						if err != nil {
+							return err
+						}
+
+						if balance < 100 {
+							return apperr.ErrInsufficientFunds
+						}
+					}
+
+					return s.Users.DecreaseBalance(ctx, 1, 100)
+				})
+
+				switch {
+				case err == nil:
+					succeeded.Add(1)
+				case !errors.Is(err, apperr.ErrInsufficientFunds):
+					t.Errorf("decrement: %v", err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if got := succeeded.Load(); got != 10 {
+			t.Fatalf("successful decrements: want 10, got %d", got)
+		}
+
+		wantBalance(t, s, 1, 0)
+	})
+}
+
+func wantBalance(t *testing.T, s Store, userID uint64, want money.Amount) {
+	t.Helper()
+
+	got, err := s.Users.GetBalance(t.Context(), userID)
+	if err != nil {
+		t.Fatalf("get balance: %v", err)
+	}
+
+	if got != want {
+		t.Fatalf("balance: want %s, got %s", want, got)
+	}
+}
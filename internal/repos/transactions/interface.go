@@ -1,12 +1,10 @@
 package transactions
 
-import (
-	"database/sql"
-	"errors"
-)
-
-var ErrDuplicateTransaction = errors.New("duplicate transaction")
+import "context"
 
+// Transactions records processed transaction IDs. Insert reports an already
+// recorded ID as apperr.ErrDuplicateTransaction. Insert runs inside the
+// transaction carried by ctx (see package txn), if any.
 type Transactions interface {
-	Insert(tx *sql.Tx, txid string, userID uint64) error
+	Insert(ctx context.Context, txid string, userID uint64) error
 }
@@ -0,0 +1,105 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	"github.com/fastprodman/EntainHW/internal/repos/transactions"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+)
+
+var _ transactions.Transactions = (*transactionsRepo)(nil)
+
+// claim is a recorded transaction ID. Until the inserting transaction ends it
+// is pending and done is open; a concurrent Insert of the same ID waits on it,
+// like on a uniqueness check against an uncommitted row.
+type claim struct {
+	owner     *memtx.Tx
+	committed bool
+	done      chan struct{}
+}
+
+type transactionsRepo struct {
+	users users.Users
+
+	mu     sync.Mutex
+	claims map[string]*claim
+}
+
+// New returns an empty in-memory transaction ID store. users stands in for
+// the foreign key: inserting for a missing user reports
+// apperr.ErrUserNotFound.
+func New(users users.Users) *transactionsRepo {
+	return &transactionsRepo{users: users, claims: map[string]*claim{}}
+}
+
+func (r *transactionsRepo) Insert(ctx context.Context, txid string, userID uint64) error {
+	err := r.users.Exists(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("insert transaction: %w", err)
+	}
+
+	tx := memtx.FromContext(ctx)
+
+	c, err := r.claim(ctx, tx, txid)
+	if err != nil {
+		return err
+	}
+
+	if tx == nil {
+		r.settle(txid, c, true)
+
+		return nil
+	}
+
+	tx.OnEnd(func(committed bool) { r.settle(txid, c, committed) })
+
+	return nil
+}
+
+// claim reserves txid for tx, waiting out a pending claim by another
+// transaction.
+func (r *transactionsRepo) claim(ctx context.Context, tx *memtx.Tx, txid string) (*claim, error) {
+	for {
+		r.mu.Lock()
+
+		existing, ok := r.claims[txid]
+		if !ok {
+			c := &claim{owner: tx, done: make(chan struct{})}
+			r.claims[txid] = c
+			r.mu.Unlock()
+
+			return c, nil
+		}
+
+		duplicate := existing.committed || (tx != nil && existing.owner == tx)
+		r.mu.Unlock()
+
+		if duplicate {
+			return nil, apperr.ErrDuplicateTransaction
+		}
+
+		select {
+		case <-existing.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for concurrent insert: %w", ctx.Err())
+		}
+	}
+}
+
+// settle keeps c on commit and frees txid on rollback.
+func (r *transactionsRepo) settle(txid string, c *claim, committed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if committed {
+		c.committed = true
+	} else {
+		delete(r.claims, txid)
+	}
+
+	close(c.done)
+}
@@ -0,0 +1,82 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	memusers "github.com/fastprodman/EntainHW/internal/repos/users/memory"
+)
+
+func newRepo() *transactionsRepo {
+	users := memusers.New()
+	users.Put(1, 0)
+
+	return New(users)
+}
+
+func TestTransactions_Insert(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepo()
+	ctx := t.Context()
+
+	if err := repo.Insert(ctx, "tx-1", 1); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := repo.Insert(ctx, "tx-1", 1); !errors.Is(err, apperr.ErrDuplicateTransaction) {
+		t.Fatalf("duplicate: want ErrDuplicateTransaction, got %v", err)
+	}
+
+	if err := repo.Insert(ctx, "tx-2", 9); !errors.Is(err, apperr.ErrUserNotFound) {
+		t.Fatalf("missing user: want ErrUserNotFound, got %v", err)
+	}
+}
+
+// A concurrent insert of the same ID waits for the first transaction and
+// succeeds if it rolls back.
+func TestTransactions_WaitsForPendingClaim(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepo()
+	errBoom := errors.New("boom")
+	claimed := make(chan struct{})
+	rollback := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- memtx.WithTx(t.Context(), func(ctx context.Context) error {
+			err := repo.Insert(ctx, "tx-1", 1)
+			if err != nil {
+				return err
+			}
+
+			if err := repo.Insert(ctx, "tx-1", 1); !errors.Is(err, apperr.ErrDuplicateTransaction) {
+				t.Errorf("same tx duplicate: want ErrDuplicateTransaction, got %v", err)
+			}
+
+			close(claimed)
+			<-rollback
+
+			return errBoom
+		})
+	}()
+
+	<-claimed
+
+	second := make(chan error, 1)
+	go func() { second <- repo.Insert(t.Context(), "tx-1", 1) }()
+
+	close(rollback)
+
+	if err := <-done; !errors.Is(err, errBoom) {
+		t.Fatalf("first: want %v, got %v", errBoom, err)
+	}
+
+	if err := <-second; err != nil {
+		t.Fatalf("second insert after rollback: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/transactions"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ transactions.Transactions = (*transactionsRepo)(nil)
+
+type transactionsRepo struct {
+	pool *pgxpool.Pool
+	opts pgutils.RepoOptions
+}
+
+func New(pool *pgxpool.Pool, opts ...pgutils.RepoOption) *transactionsRepo {
+	return &transactionsRepo{pool: pool, opts: pgutils.NewRepoOptions(opts...)}
+}
+
+func (r *transactionsRepo) Insert(ctx context.Context, txid string, userID uint64) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "transactions.Insert")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	_, err = pgxutils.Conn(ctx, r.pool).Exec(ctx, `
+		WITH claim AS (
+			INSERT INTO transaction_keys (transaction_id)
+			VALUES ($1)
+			RETURNING transaction_id, created_at
+		)
+		INSERT INTO transactions (transaction_id, user_id, created_at)
+		SELECT transaction_id, $2, created_at FROM claim
+	`, txid, userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == "23505" { // unique_violation on transaction_keys
+				return apperr.ErrDuplicateTransaction
+			}
+		}
+
+		return fmt.Errorf("insert transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package transactions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTransactions_Pgx_Insert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		txid    string
+		userID  uint64
+		wantErr error
+	}{
+		{name: "ok_insert", txid: "tx_new", userID: 1},
+		{name: "duplicate_transaction", txid: "tx_dup", userID: 1, wantErr: apperr.ErrDuplicateTransaction},
+		{name: "user_not_exist_fk_violation", txid: "tx_fk", userID: 999, wantErr: &pgconn.PgError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, cleanup := pgtestutil.NewTestPool(t)
+			defer cleanup()
+
+			pgtestutil.SeedPool(t, pool, pgtestutil.Fixture{
+				Users:        []pgtestutil.User{{ID: 1, Balance: 100}},
+				Transactions: []pgtestutil.Transaction{{ID: "tx_dup", UserID: 1}},
+			})
+
+			err := New(pool).Insert(t.Context(), tt.txid, tt.userID)
+
+			var pgErr *pgconn.PgError
+
+			switch {
+			case tt.wantErr == nil:
+				if err != nil {
+					t.Fatalf("insert: %v", err)
+				}
+			case errors.As(tt.wantErr, &pgErr):
+				if !errors.As(err, &pgErr) {
+					t.Fatalf("want a pg error, got %v", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v, got %v", tt.wantErr, err)
+				}
+			}
+		})
+	}
+}
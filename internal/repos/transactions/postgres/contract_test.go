@@ -0,0 +1,9 @@
+package transactions_test
+
+import (
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/repos/repotest"
+)
+
+func TestContract(t *testing.T) { repotest.RunTransactions(t, repotest.Postgres) }
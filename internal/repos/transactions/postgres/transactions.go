@@ -1,32 +1,50 @@
 package transactions
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
 	"github.com/fastprodman/EntainHW/internal/repos/transactions"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var _ transactions.Transactions = (*transactionsRepo)(nil)
 
-type transactionsRepo struct{ db *sql.DB }
+type transactionsRepo struct {
+	db   *sql.DB
+	opts pgutils.RepoOptions
+}
 
-func New(db *sql.DB) *transactionsRepo {
-	return &transactionsRepo{db: db}
+func New(db *sql.DB, opts ...pgutils.RepoOption) *transactionsRepo {
+	return &transactionsRepo{db: db, opts: pgutils.NewRepoOptions(opts...)}
 }
 
-func (r *transactionsRepo) Insert(tx *sql.Tx, txid string, userID uint64) error {
-	_, err := tx.Exec(`
-		INSERT INTO transactions (transaction_id, user_id)
-		VALUES ($1, $2)
+func (r *transactionsRepo) Insert(ctx context.Context, txid string, userID uint64) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "transactions.Insert")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	_, err = pgutils.Conn(ctx, r.db).ExecContext(ctx, `
+		WITH claim AS (
+			INSERT INTO transaction_keys (transaction_id)
+			VALUES ($1)
+			RETURNING transaction_id, created_at
+		)
+		INSERT INTO transactions (transaction_id, user_id, created_at)
+		SELECT transaction_id, $2, created_at FROM claim
 	`, txid, userID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" { // unique_violation
-				return transactions.ErrDuplicateTransaction
+			if pgErr.Code == "23505" { // unique_violation on transaction_keys
+				return apperr.ErrDuplicateTransaction
 			}
 		}
 
@@ -6,8 +6,9 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/fastprodman/EntainHW/internal/apperr"
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
-	"github.com/fastprodman/EntainHW/internal/repos/transactions"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -36,18 +37,14 @@ func TestTransactions_Insert(t *testing.T) {
 		{
 			name: "duplicate_transaction",
 			seed: func(db *sql.DB) {
-				_, err := db.Exec(`INSERT INTO users (id, balance) VALUES ($1, $2)`, 2, 100)
-				if err != nil {
-					t.Fatalf("seed user: %v", err)
-				}
-				_, err = db.Exec(`INSERT INTO transactions (transaction_id, user_id) VALUES ($1, $2)`, "tx_dup", 2)
-				if err != nil {
-					t.Fatalf("seed tx: %v", err)
-				}
+				pgtestutil.Seed(t, db, pgtestutil.Fixture{
+					Users:        []pgtestutil.User{{ID: 2, Balance: 100}},
+					Transactions: []pgtestutil.Transaction{{ID: "tx_dup", UserID: 2}},
+				})
 			},
 			txid:    "tx_dup",
 			userID:  2,
-			wantErr: transactions.ErrDuplicateTransaction,
+			wantErr: apperr.ErrDuplicateTransaction,
 		},
 		{
 			name:    "user_not_exist_fk_violation",
@@ -79,7 +76,7 @@ func TestTransactions_Insert(t *testing.T) {
 			}
 			defer tx.Rollback()
 
-			err = repo.Insert(tx, tt.txid, tt.userID)
+			err = repo.Insert(pgutils.ContextWithTx(ctx, tx), tt.txid, tt.userID)
 
 			if tt.wantErr == nil {
 				if err != nil {
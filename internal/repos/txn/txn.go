@@ -0,0 +1,77 @@
+// Package txn describes units of work that span several repo calls,
+// independently of the storage backend.
+//
+// A Manager runs fn inside a transaction and hands it a context carrying that
+// transaction; every repo method called with that context takes part in it.
+// Repo methods called with a context that carries no transaction run on their
+// own.
+package txn
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Manager runs a unit of work in a transaction.
+//
+// With WithRetry, fn may be called several times, each time on a fresh
+// transaction. It must therefore derive everything from what it reads inside
+// the transaction and must not have side effects outside of it.
+type Manager interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error
+}
+
+// Option configures a transaction.
+type Option func(*Options)
+
+// Options is the resolved transaction configuration.
+type Options struct {
+	Isolation   sql.IsolationLevel
+	ReadOnly    bool
+	MaxAttempts int
+	BaseBackoff time.Duration
+	Timeout     time.Duration
+}
+
+const defaultBaseBackoff = 5 * time.Millisecond
+
+// WithIsolation sets the transaction isolation level.
+func WithIsolation(level sql.IsolationLevel) Option {
+	return func(o *Options) { o.Isolation = level }
+}
+
+// ReadOnly starts the transaction in read-only mode.
+func ReadOnly() Option {
+	return func(o *Options) { o.ReadOnly = true }
+}
+
+// WithRetry re-runs the transaction up to maxAttempts times in total when it
+// fails with a serialization failure or deadlock, sleeping a jittered
+// exponential backoff starting at baseBackoff between attempts.
+func WithRetry(maxAttempts int, baseBackoff time.Duration) Option {
+	return func(o *Options) {
+		o.MaxAttempts = max(1, maxAttempts)
+		o.BaseBackoff = baseBackoff
+	}
+}
+
+// WithTimeout bounds the whole transaction, retries included.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.Timeout = d }
+}
+
+// NewOptions applies opts over the defaults: default isolation, read-write,
+// a single attempt and no timeout.
+func NewOptions(opts ...Option) Options {
+	o := Options{
+		Isolation:   sql.LevelDefault,
+		MaxAttempts: 1,
+		BaseBackoff: defaultBaseBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
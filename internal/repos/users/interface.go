@@ -2,17 +2,20 @@ package users
 
 import (
 	"context"
-	"database/sql"
-	"errors"
-)
 
-var ErrInsufficientFunds = errors.New("insufficient funds")
-var ErrUserNotFound = errors.New("user not found")
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
 
+// Users is the user/balance store. Exists and GetBalance report missing users
+// as apperr.ErrUserNotFound; DecreaseBalance reports apperr.ErrInsufficientFunds
+// when the balance would go negative.
+//
+// Methods run inside the transaction carried by ctx (see package txn), or on
+// their own when there is none. LockAndGetBalance only makes sense inside one.
 type Users interface {
-	Exists(tx *sql.Tx, userID uint64) error
-	GetBalance(ctx context.Context, userID uint64) (int64, error)
-	LockAndGetBalance(tx *sql.Tx, userID uint64) (int64, error)
-	IncreaseBalance(tx *sql.Tx, userID uint64, amount int64) error
-	DecreaseBalance(tx *sql.Tx, userID uint64, amount int64) error
+	Exists(ctx context.Context, userID uint64) error
+	GetBalance(ctx context.Context, userID uint64) (money.Amount, error)
+	LockAndGetBalance(ctx context.Context, userID uint64) (money.Amount, error)
+	IncreaseBalance(ctx context.Context, userID uint64, amount money.Amount) error
+	DecreaseBalance(ctx context.Context, userID uint64, amount money.Amount) error
 }
@@ -0,0 +1,165 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+var _ users.Users = (*usersRepo)(nil)
+
+// user is one row. lock plays the part of the row lock; balance holds the
+// committed value, read without the lock like an MVCC snapshot.
+type user struct {
+	lock    *memtx.Lock
+	balance atomic.Int64
+}
+
+type usersRepo struct {
+	mu    sync.RWMutex
+	users map[uint64]*user
+}
+
+// New returns an empty in-memory user store. Balance writes made inside a
+// memtx transaction become visible when it commits.
+func New() *usersRepo {
+	return &usersRepo{users: map[uint64]*user{}}
+}
+
+// Put creates the user, or overwrites its balance.
+func (r *usersRepo) Put(userID uint64, balance money.Amount) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok {
+		u = &user{lock: memtx.NewLock()}
+		r.users[userID] = u
+	}
+
+	u.balance.Store(int64(balance))
+}
+
+func (r *usersRepo) Exists(_ context.Context, userID uint64) error {
+	if r.get(userID) == nil {
+		return apperr.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *usersRepo) GetBalance(ctx context.Context, userID uint64) (money.Amount, error) {
+	u := r.get(userID)
+	if u == nil {
+		return 0, apperr.ErrUserNotFound
+	}
+
+	return current(memtx.FromContext(ctx), u), nil
+}
+
+func (r *usersRepo) LockAndGetBalance(ctx context.Context, userID uint64) (money.Amount, error) {
+	u := r.get(userID)
+	if u == nil {
+		return 0, apperr.ErrUserNotFound
+	}
+
+	var balance money.Amount
+
+	err := memtx.Locked(ctx, u.lock, func(tx *memtx.Tx) error {
+		balance = current(tx, u)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return balance, nil
+}
+
+// IncreaseBalance is a no-op for a missing user, like an UPDATE matching no
+// row.
+func (r *usersRepo) IncreaseBalance(ctx context.Context, userID uint64, amount money.Amount) error {
+	u := r.get(userID)
+	if u == nil {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return memtx.Locked(ctx, u.lock, func(tx *memtx.Tx) error {
+		balance, err := current(tx, u).Add(amount)
+		if err != nil {
+			return err
+		}
+
+		write(tx, u, balance)
+
+		return nil
+	})
+}
+
+// DecreaseBalance reports a missing user as apperr.ErrInsufficientFunds, like
+// the guarded UPDATE matching no row.
+func (r *usersRepo) DecreaseBalance(ctx context.Context, userID uint64, amount money.Amount) error {
+	u := r.get(userID)
+	if u == nil {
+		return apperr.ErrInsufficientFunds
+	}
+
+	//nolint:wrapcheck
+	return memtx.Locked(ctx, u.lock, func(tx *memtx.Tx) error {
+		balance := current(tx, u)
+		if balance < amount {
+			return apperr.ErrInsufficientFunds
+		}
+
+		write(tx, u, balance-amount)
+
+		return nil
+	})
+}
+
+func (r *usersRepo) get(userID uint64) *user {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.users[userID]
+}
+
+// current is u's balance as seen by tx: its own staged write, if any,
+// otherwise the committed value.
+func current(tx *memtx.Tx, u *user) money.Amount {
+	if tx != nil {
+		if v, ok := tx.Get(u); ok {
+			return v.(money.Amount) //nolint:forcetypeassert
+		}
+	}
+
+	return money.Amount(u.balance.Load())
+}
+
+// write stores balance for u: immediately without a transaction, on commit
+// within one. The caller holds u.lock.
+func write(tx *memtx.Tx, u *user, balance money.Amount) {
+	if tx == nil {
+		u.balance.Store(int64(balance))
+
+		return
+	}
+
+	if _, staged := tx.Get(u); !staged {
+		tx.OnEnd(func(committed bool) {
+			if committed {
+				v, _ := tx.Get(u)
+				u.balance.Store(int64(v.(money.Amount))) //nolint:forcetypeassert
+			}
+		})
+	}
+
+	tx.Set(u, balance)
+}
@@ -0,0 +1,85 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestUsers_WritesVisibleOnCommit(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name  string
+		fnErr error
+		want  money.Amount
+	}{
+		{name: "commit", want: 70},
+		{name: "rollback", fnErr: errBoom, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := New()
+			repo.Put(1, 100)
+
+			err := memtx.WithTx(t.Context(), func(ctx context.Context) error {
+				err := repo.DecreaseBalance(ctx, 1, 30)
+				if err != nil {
+					return err
+				}
+
+				inTx, _ := repo.GetBalance(ctx, 1)
+				if inTx != 70 {
+					t.Fatalf("inside tx: want 70, got %s", inTx)
+				}
+
+				outside, _ := repo.GetBalance(context.Background(), 1)
+				if outside != 100 {
+					t.Fatalf("outside tx before commit: want 100, got %s", outside)
+				}
+
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) {
+				t.Fatalf("want %v, got %v", tt.fnErr, err)
+			}
+
+			got, _ := repo.GetBalance(t.Context(), 1)
+			if got != tt.want {
+				t.Fatalf("after tx: want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUsers_MissingUser(t *testing.T) {
+	t.Parallel()
+
+	repo := New()
+	ctx := t.Context()
+
+	if err := repo.Exists(ctx, 9); !errors.Is(err, apperr.ErrUserNotFound) {
+		t.Fatalf("exists: want ErrUserNotFound, got %v", err)
+	}
+
+	if _, err := repo.GetBalance(ctx, 9); !errors.Is(err, apperr.ErrUserNotFound) {
+		t.Fatalf("get balance: want ErrUserNotFound, got %v", err)
+	}
+
+	if err := repo.IncreaseBalance(ctx, 9, 10); err != nil {
+		t.Fatalf("increase: want no-op, got %v", err)
+	}
+
+	if err := repo.DecreaseBalance(ctx, 9, 10); !errors.Is(err, apperr.ErrInsufficientFunds) {
+		t.Fatalf("decrease: want ErrInsufficientFunds, got %v", err)
+	}
+}
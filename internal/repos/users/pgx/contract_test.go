@@ -0,0 +1,9 @@
+package users_test
+
+import (
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/repos/repotest"
+)
+
+func TestContract(t *testing.T) { repotest.RunUsers(t, repotest.Pgx) }
@@ -0,0 +1,36 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func (r *usersRepo) DecreaseBalance(ctx context.Context, userID uint64, amount money.Amount) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.DecreaseBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	tag, err := pgxutils.Conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users
+		SET balance = balance - $2
+		WHERE id = $1
+		  AND balance >= $2
+	`, userID, amount)
+	if err != nil {
+		return fmt.Errorf("decrease balance: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperr.ErrInsufficientFunds
+	}
+
+	return nil
+}
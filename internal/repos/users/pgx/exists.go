@@ -0,0 +1,34 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+)
+
+func (r *usersRepo) Exists(ctx context.Context, userID uint64) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.Exists")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var exists bool
+
+	err = pgxutils.Conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)
+	`, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check exists: %w", err)
+	}
+
+	if !exists {
+		return apperr.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *usersRepo) GetBalance(ctx context.Context, userID uint64) (_ money.Amount, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.GetBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var balance money.Amount
+
+	err = pgxutils.Conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT balance
+		FROM users
+		WHERE id = $1
+	`, userID).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, apperr.ErrUserNotFound
+		}
+
+		return 0, fmt.Errorf("get balance: %w", err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,30 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func (r *usersRepo) IncreaseBalance(ctx context.Context, userID uint64, amount money.Amount) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.IncreaseBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	_, err = pgxutils.Conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users
+		SET balance = balance + $2
+		WHERE id = $1
+	`, userID, amount)
+	if err != nil {
+		return fmt.Errorf("increase balance: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func (r *usersRepo) LockAndGetBalance(ctx context.Context, userID uint64) (_ money.Amount, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.LockAndGetBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var balance money.Amount
+
+	err = pgxutils.Conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT balance
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`, userID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("lock/get balance: %w", err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,18 @@
+package users
+
+import (
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ users.Users = (*usersRepo)(nil)
+
+type usersRepo struct {
+	pool *pgxpool.Pool
+	opts pgutils.RepoOptions
+}
+
+func New(pool *pgxpool.Pool, opts ...pgutils.RepoOption) *usersRepo {
+	return &usersRepo{pool: pool, opts: pgutils.NewRepoOptions(opts...)}
+}
@@ -0,0 +1,143 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestUsers_Pgx_Table(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		seedBalance money.Amount // user 1 is seeded with this balance
+		run         func(ctx context.Context, repo *usersRepo) error
+		wantErr     error
+		wantBalance money.Amount // balance of user 1 afterwards
+	}{
+		{
+			name:        "exists",
+			seedBalance: 100,
+			run:         func(ctx context.Context, repo *usersRepo) error { return repo.Exists(ctx, 1) },
+			wantBalance: 100,
+		},
+		{
+			name:        "exists_missing_user",
+			seedBalance: 100,
+			run:         func(ctx context.Context, repo *usersRepo) error { return repo.Exists(ctx, 2) },
+			wantErr:     apperr.ErrUserNotFound,
+			wantBalance: 100,
+		},
+		{
+			name:        "get_balance_missing_user",
+			seedBalance: 100,
+			run: func(ctx context.Context, repo *usersRepo) error {
+				_, err := repo.GetBalance(ctx, 2)
+
+				return err
+			},
+			wantErr:     apperr.ErrUserNotFound,
+			wantBalance: 100,
+		},
+		{
+			name:        "lock_and_increase",
+			seedBalance: 100,
+			run: func(ctx context.Context, repo *usersRepo) error {
+				bal, err := repo.LockAndGetBalance(ctx, 1)
+				if err != nil {
+					return err
+				}
+
+				return repo.IncreaseBalance(ctx, 1, bal)
+			},
+			wantBalance: 200,
+		},
+		{
+			name:        "decrease_to_zero",
+			seedBalance: 100,
+			run:         func(ctx context.Context, repo *usersRepo) error { return repo.DecreaseBalance(ctx, 1, 100) },
+			wantBalance: 0,
+		},
+		{
+			name:        "decrease_insufficient_funds",
+			seedBalance: 100,
+			run:         func(ctx context.Context, repo *usersRepo) error { return repo.DecreaseBalance(ctx, 1, 101) },
+			wantErr:     apperr.ErrInsufficientFunds,
+			wantBalance: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool, cleanup := pgtestutil.NewTestPool(t)
+			defer cleanup()
+
+			_, err := pool.Exec(t.Context(), `INSERT INTO users (id, balance) VALUES ($1, $2)`, 1, tt.seedBalance)
+			if err != nil {
+				t.Fatalf("seed user: %v", err)
+			}
+
+			repo := New(pool)
+
+			err = pgxutils.WithTx(t.Context(), pool, func(ctx context.Context) error {
+				return tt.run(ctx, repo)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v, got %v", tt.wantErr, err)
+			}
+
+			assertBalance(t, pool, 1, tt.wantBalance)
+		})
+	}
+}
+
+// A failed transaction must not leave any of its writes behind.
+func TestUsers_Pgx_RollbackDiscardsWrites(t *testing.T) {
+	t.Parallel()
+
+	pool, cleanup := pgtestutil.NewTestPool(t)
+	defer cleanup()
+
+	_, err := pool.Exec(t.Context(), `INSERT INTO users (id, balance) VALUES ($1, $2)`, 1, 100)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+
+	repo := New(pool)
+
+	err = pgxutils.WithTx(t.Context(), pool, func(ctx context.Context) error {
+		err := repo.IncreaseBalance(ctx, 1, 50)
+		if err != nil {
+			return err
+		}
+
+		return repo.DecreaseBalance(ctx, 1, 1_000)
+	})
+	if !errors.Is(err, apperr.ErrInsufficientFunds) {
+		t.Fatalf("want %v, got %v", apperr.ErrInsufficientFunds, err)
+	}
+
+	assertBalance(t, pool, 1, 100)
+}
+
+func assertBalance(t *testing.T, pool *pgxpool.Pool, userID uint64, want money.Amount) {
+	t.Helper()
+
+	got, err := New(pool).GetBalance(t.Context(), userID)
+	if err != nil {
+		t.Fatalf("get balance: %v", err)
+	}
+
+	if got != want {
+		t.Fatalf("balance: want %s, got %s", want, got)
+	}
+}
@@ -1,14 +1,23 @@
 package users
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
-func (r *usersRepo) DecreaseBalance(tx *sql.Tx, userID uint64, amount int64) error {
-	res, err := tx.Exec(`
+func (r *usersRepo) DecreaseBalance(ctx context.Context, userID uint64, amount money.Amount) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.DecreaseBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	res, err := pgutils.Conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET balance = balance - $2
 		WHERE id = $1
@@ -24,7 +33,7 @@ func (r *usersRepo) DecreaseBalance(tx *sql.Tx, userID uint64, amount int64) err
 	}
 
 	if affected == 0 {
-		return users.ErrInsufficientFunds
+		return apperr.ErrInsufficientFunds
 	}
 
 	return nil
@@ -8,8 +8,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fastprodman/EntainHW/internal/apperr"
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
 func TestUsers_DecreaseBalance_Table(t *testing.T) {
@@ -20,9 +22,9 @@ func TestUsers_DecreaseBalance_Table(t *testing.T) {
 		name          string
 		seed          seedFn
 		userID        uint64
-		amount        int64
-		wantBalance   int64
-		wantErr       bool // true -> expect usersdom.ErrInsufficientFunds
+		amount        money.Amount
+		wantBalance   money.Amount
+		wantErr       bool // true -> expect apperr.ErrInsufficientFunds
 		checkFinalBal bool // whether to check final balance (skip if user doesn't exist)
 	}
 
@@ -98,13 +100,13 @@ func TestUsers_DecreaseBalance_Table(t *testing.T) {
 			}
 			defer func() { _ = tx.Rollback() }()
 
-			err = repo.DecreaseBalance(tx, tt.userID, tt.amount)
+			err = repo.DecreaseBalance(pgutils.ContextWithTx(ctx, tx), tt.userID, tt.amount)
 
 			if tt.wantErr {
 				if err == nil {
 					t.Fatalf("expected error (insufficient or missing), got nil")
 				}
-				if !errors.Is(err, users.ErrInsufficientFunds) {
+				if !errors.Is(err, apperr.ErrInsufficientFunds) {
 					t.Fatalf("expected ErrInsufficientFunds, got: %v", err)
 				}
 				// no commit on error
@@ -161,14 +163,14 @@ func TestUsers_DecreaseBalance_ConcurrentGuard(t *testing.T) {
 		defer tx.Rollback()
 
 		// Lock row first (this will serialize)
-		_, err = repo.LockAndGetBalance(tx, 1)
+		_, err = repo.LockAndGetBalance(pgutils.ContextWithTx(ctx, tx), 1)
 		if err != nil {
 			t.Errorf("[%s] lock balance: %v", name, err)
 			return
 		}
 
 		// Try to decrease 1000
-		err = repo.DecreaseBalance(tx, 1, 1000)
+		err = repo.DecreaseBalance(pgutils.ContextWithTx(ctx, tx), 1, 1000)
 		if err == nil {
 			mu.Lock()
 			success++
@@ -179,7 +181,7 @@ func TestUsers_DecreaseBalance_ConcurrentGuard(t *testing.T) {
 			return
 		}
 
-		if errors.Is(err, users.ErrInsufficientFunds) {
+		if errors.Is(err, apperr.ErrInsufficientFunds) {
 			mu.Lock()
 			insufficient++
 			mu.Unlock()
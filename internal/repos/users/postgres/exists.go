@@ -1,16 +1,24 @@
 package users
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
 )
 
-func (r *usersRepo) Exists(tx *sql.Tx, userID uint64) error {
+func (r *usersRepo) Exists(ctx context.Context, userID uint64) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.Exists")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
 	var exists bool
 
-	err := tx.QueryRow(`
+	err = pgutils.Conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)
 	`, userID).Scan(&exists)
 	if err != nil {
@@ -18,7 +26,7 @@ func (r *usersRepo) Exists(tx *sql.Tx, userID uint64) error {
 	}
 
 	if !exists {
-		return users.ErrUserNotFound
+		return apperr.ErrUserNotFound
 	}
 
 	return nil
@@ -6,8 +6,9 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/fastprodman/EntainHW/internal/apperr"
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
 )
 
 func TestUsers_Exists_TableDriven(t *testing.T) {
@@ -34,7 +35,7 @@ func TestUsers_Exists_TableDriven(t *testing.T) {
 			name:    "user not found",
 			seed:    func(db *sql.DB) {}, // no user
 			userID:  999,
-			wantErr: users.ErrUserNotFound,
+			wantErr: apperr.ErrUserNotFound,
 		},
 	}
 
@@ -60,7 +61,7 @@ func TestUsers_Exists_TableDriven(t *testing.T) {
 			}
 			defer tx.Rollback()
 
-			err = repo.Exists(tx, tt.userID)
+			err = repo.Exists(pgutils.ContextWithTx(ctx, tx), tt.userID)
 
 			if !errors.Is(err, tt.wantErr) {
 				t.Fatalf("unexpected error: got %v, want %v", err, tt.wantErr)
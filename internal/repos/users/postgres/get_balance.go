@@ -6,20 +6,29 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
-func (r *usersRepo) GetBalance(ctx context.Context, userID uint64) (int64, error) {
-	var balance int64
+func (r *usersRepo) GetBalance(ctx context.Context, userID uint64) (_ money.Amount, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.GetBalance")
+	defer func() { tracing.End(span, err) }()
 
-	err := r.db.QueryRowContext(ctx, `
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var balance money.Amount
+
+	err = pgutils.Conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT balance
 		FROM users
 		WHERE id = $1
 	`, userID).Scan(&balance)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, users.ErrUserNotFound
+			return 0, apperr.ErrUserNotFound
 		}
 
 		return 0, fmt.Errorf("get balance: %w", err)
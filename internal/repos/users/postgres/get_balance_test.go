@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
 func TestUsers_GetBalance_TableDriven(t *testing.T) {
@@ -14,7 +15,7 @@ func TestUsers_GetBalance_TableDriven(t *testing.T) {
 		name        string
 		seed        func(db *sql.DB, t *testing.T)
 		userID      uint64
-		wantBalance int64
+		wantBalance money.Amount
 		wantErr     bool
 	}
 
@@ -1,12 +1,22 @@
 package users
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
-func (r *usersRepo) IncreaseBalance(tx *sql.Tx, userID uint64, amount int64) error {
-	_, err := tx.Exec(`
+func (r *usersRepo) IncreaseBalance(ctx context.Context, userID uint64, amount money.Amount) (err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.IncreaseBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	_, err = pgutils.Conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET balance = balance + $2
 		WHERE id = $1
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
 func TestUsers_IncreaseBalance_Basic(t *testing.T) {
@@ -18,8 +20,8 @@ func TestUsers_IncreaseBalance_Basic(t *testing.T) {
 		name        string
 		seed        seedFn
 		userID      uint64
-		amount      int64
-		wantBalance int64
+		amount      money.Amount
+		wantBalance money.Amount
 	}
 
 	upsert := func(db *sql.DB, id uint64, bal int64, t *testing.T) {
@@ -79,7 +81,7 @@ func TestUsers_IncreaseBalance_Basic(t *testing.T) {
 			}
 			defer func() { _ = tx.Rollback() }()
 
-			err = repo.IncreaseBalance(tx, tt.userID, tt.amount)
+			err = repo.IncreaseBalance(pgutils.ContextWithTx(ctx, tx), tt.userID, tt.amount)
 			if err != nil {
 				t.Fatalf("increase balance: %v", err)
 			}
@@ -120,7 +122,7 @@ func TestUsers_IncreaseBalance_ConcurrentAdds(t *testing.T) {
 	errCh := make(chan error, 2)
 	doneCh := make(chan struct{}, 2)
 
-	worker := func(amount int64) {
+	worker := func(amount money.Amount) {
 		defer func() { doneCh <- struct{}{} }()
 
 		tx, e := db.BeginTx(ctx, nil)
@@ -130,7 +132,7 @@ func TestUsers_IncreaseBalance_ConcurrentAdds(t *testing.T) {
 		}
 		defer func() { _ = tx.Rollback() }()
 
-		e = repo.IncreaseBalance(tx, 777, amount)
+		e = repo.IncreaseBalance(pgutils.ContextWithTx(ctx, tx), 777, amount)
 		if e != nil {
 			errCh <- e
 			return
@@ -164,7 +166,7 @@ func TestUsers_IncreaseBalance_ConcurrentAdds(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get balance: %v", err)
 	}
-	want := int64(3_500)
+	want := money.Amount(3_500)
 	if got != want {
 		t.Fatalf("final balance mismatch: want %d, got %d", want, got)
 	}
@@ -189,7 +191,7 @@ func TestUsers_IncreaseBalance_UserNotFound_CurrentBehavior(t *testing.T) {
 	defer func() { _ = tx.Rollback() }()
 
 	// Call IncreaseBalance for a user that doesn't exist.
-	err = repo.IncreaseBalance(tx, 999_999, 100)
+	err = repo.IncreaseBalance(pgutils.ContextWithTx(ctx, tx), 999_999, 100)
 	if err != nil {
 		t.Fatalf("increase balance unexpected error: %v", err)
 	}
@@ -206,7 +208,7 @@ func TestUsers_IncreaseBalance_UserNotFound_CurrentBehavior(t *testing.T) {
 	}
 	// Usually your repo maps to domain error; if not, expect sql.ErrNoRows.
 	if !errors.Is(err, sql.ErrNoRows) {
-		// If you have a domain error like users.ErrUserNotFound, replace sql.ErrNoRows above.
+		// If you have a domain error like apperr.ErrUserNotFound, replace sql.ErrNoRows above.
 		t.Logf("note: GetBalance returned a non-sql.ErrNoRows error: %v", err)
 	}
 }
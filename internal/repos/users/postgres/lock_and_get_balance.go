@@ -1,14 +1,24 @@
 package users
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
-func (r *usersRepo) LockAndGetBalance(tx *sql.Tx, userID uint64) (int64, error) {
-	var balance int64
+func (r *usersRepo) LockAndGetBalance(ctx context.Context, userID uint64) (_ money.Amount, err error) {
+	ctx, span := pgutils.StartSpan(ctx, "users.LockAndGetBalance")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := r.opts.QueryContext(ctx)
+	defer cancel()
+
+	var balance money.Amount
 
-	err := tx.QueryRow(`
+	err = pgutils.Conn(ctx, r.db).QueryRowContext(ctx, `
 		SELECT balance
 		FROM users
 		WHERE id = $1
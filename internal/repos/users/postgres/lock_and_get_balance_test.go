@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/pkg/money"
 )
 
 func TestUsers_LockAndGetBalance_Table(t *testing.T) {
@@ -19,7 +21,7 @@ func TestUsers_LockAndGetBalance_Table(t *testing.T) {
 		name        string
 		seed        seedFn
 		userID      uint64
-		wantBalance int64
+		wantBalance money.Amount
 		wantErr     bool // true => expect error (e.g., user not found)
 	}
 
@@ -65,7 +67,7 @@ func TestUsers_LockAndGetBalance_Table(t *testing.T) {
 				}
 			},
 			userID:      3,
-			wantBalance: int64(900_000_000_000_000),
+			wantBalance: money.Amount(900_000_000_000_000),
 			wantErr:     false,
 		},
 	}
@@ -97,7 +99,7 @@ func TestUsers_LockAndGetBalance_Table(t *testing.T) {
 			}
 			defer func() { _ = tx.Rollback() }()
 
-			bal, err := repo.LockAndGetBalance(tx, tt.userID)
+			bal, err := repo.LockAndGetBalance(pgutils.ContextWithTx(ctx, tx), tt.userID)
 
 			if tt.wantErr {
 				if err == nil {
@@ -149,7 +151,7 @@ func TestUsers_LockAndGetBalance_LocksRow(t *testing.T) {
 	}
 	defer func() { _ = tx1.Rollback() }()
 
-	_, err = repo.LockAndGetBalance(tx1, 42)
+	_, err = repo.LockAndGetBalance(pgutils.ContextWithTx(ctx1, tx1), 42)
 	if err != nil {
 		t.Fatalf("tx1 lock/get: %v", err)
 	}
@@ -175,7 +177,7 @@ func TestUsers_LockAndGetBalance_LocksRow(t *testing.T) {
 		// Signal that we started and will likely block on FOR UPDATE
 		close(blockedCh)
 
-		_, e = repo.LockAndGetBalance(tx2, 42)
+		_, e = repo.LockAndGetBalance(pgutils.ContextWithTx(ctx2, tx2), 42)
 		if e != nil {
 			errCh <- e
 			return
@@ -216,3 +218,52 @@ func TestUsers_LockAndGetBalance_LocksRow(t *testing.T) {
 		t.Fatal("timeout waiting for tx2 to complete after tx1 commit")
 	}
 }
+
+// With lock_timeout set, a second FOR UPDATE on a locked row must fail fast
+// with a timeout instead of waiting for the holder.
+func TestUsers_LockAndGetBalance_LockTimeout(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := pgtestutil.NewTestDB(t)
+	defer cleanup()
+
+	_, err := db.Exec(`INSERT INTO users (id, balance) VALUES ($1, $2)`, 42, 200)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+
+	repo := New(db)
+
+	tx1, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("begin tx1: %v", err)
+	}
+	defer func() { _ = tx1.Rollback() }()
+
+	_, err = repo.LockAndGetBalance(pgutils.ContextWithTx(t.Context(), tx1), 42)
+	if err != nil {
+		t.Fatalf("tx1 lock/get: %v", err)
+	}
+
+	tx2, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("begin tx2: %v", err)
+	}
+	defer func() { _ = tx2.Rollback() }()
+
+	_, err = tx2.Exec(`SET LOCAL lock_timeout = '50ms'`)
+	if err != nil {
+		t.Fatalf("set lock_timeout: %v", err)
+	}
+
+	start := time.Now()
+
+	_, err = repo.LockAndGetBalance(pgutils.ContextWithTx(t.Context(), tx2), 42)
+	if !pgutils.IsTimeout(t.Context(), err) {
+		t.Fatalf("want lock timeout, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("lock timeout took %v", elapsed)
+	}
+}
@@ -2,10 +2,18 @@ package users
 
 import (
 	"database/sql"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
 )
 
-type usersRepo struct{ db *sql.DB }
+var _ users.Users = (*usersRepo)(nil)
+
+type usersRepo struct {
+	db   *sql.DB
+	opts pgutils.RepoOptions
+}
 
-func New(db *sql.DB) *usersRepo {
-	return &usersRepo{db: db}
+func New(db *sql.DB, opts ...pgutils.RepoOption) *usersRepo {
+	return &usersRepo{db: db, opts: pgutils.NewRepoOptions(opts...)}
 }
@@ -3,14 +3,40 @@ package balance
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/fastprodman/EntainHW/internal/apperr"
 	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/pgxutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/ledger"
+	pgxledger "github.com/fastprodman/EntainHW/internal/repos/ledger/pgx"
+	pgledger "github.com/fastprodman/EntainHW/internal/repos/ledger/postgres"
+	"github.com/fastprodman/EntainHW/internal/repos/replication"
+	pgxreplication "github.com/fastprodman/EntainHW/internal/repos/replication/pgx"
+	pgreplication "github.com/fastprodman/EntainHW/internal/repos/replication/postgres"
 	"github.com/fastprodman/EntainHW/internal/repos/transactions"
+	pgxtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/pgx"
 	pgtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/postgres"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
 	"github.com/fastprodman/EntainHW/internal/repos/users"
+	pgxusers "github.com/fastprodman/EntainHW/internal/repos/users/pgx"
 	pgusers "github.com/fastprodman/EntainHW/internal/repos/users/postgres"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerScope = "github.com/fastprodman/EntainHW/internal/services/balance"
+
+	// Serialization failures and deadlocks are retried up to txMaxAttempts
+	// times before they are surfaced to the caller.
+	txMaxAttempts = 5
+	txBaseBackoff = 5 * time.Millisecond
 )
 
 type SourceType string
@@ -33,54 +59,211 @@ type Transaction struct {
 	UserID        uint64
 	Source        SourceType
 	State         TxState
-	AmountMinor   int64 // cents
+	Amount        money.Amount
 }
 
 type UserSnapshot struct {
-	UserID       uint64
-	BalanceMinor int64 // cents
+	UserID  uint64
+	Balance money.Amount
 }
 
-var (
-	ErrDuplicateTransaction = errors.New("duplicate transaction")
-	ErrInsufficientFunds    = errors.New("insufficient funds")
-)
-
+// BalanceService processes transactions and reads balances.
+//
+// ProcessTransaction returns a WriteToken when a read replica is configured;
+// passing it to GetBalance guarantees the read reflects that write. An empty
+// token reads whatever the replica has.
 type BalanceService interface {
-	GetBalance(ctx context.Context, userID uint64) (int64, error)
-	ProcessTransaction(ctx context.Context, transaction Transaction) error
+	GetBalance(ctx context.Context, userID uint64, after WriteToken) (money.Amount, error)
+	ProcessTransaction(ctx context.Context, transaction Transaction) (WriteToken, error)
 }
 
+// Option configures the balance service.
+type Option func(*balanceService)
+
 type balanceService struct {
-	db    *sql.DB
-	users users.Users
-	txns  transactions.Transactions
+	tm       txn.Manager
+	timeouts pgutils.Timeouts
+	users    users.Users
+	txns     transactions.Transactions
+	// ledger, when set, processes transactions in a single round trip;
+	// otherwise the step-by-step flow over users/txns is used.
+	ledger ledger.Ledger
+	// wal hands out write tokens on the primary; nil without a replica.
+	wal replication.Tracker
+	// replica serves balance reads when configured; see replica.go.
+	replica replicaStore
+	// cache fronts GetBalance when configured; see cache.go.
+	cache *BalanceCache
 }
 
-func New(dbx *sql.DB) *balanceService {
-	return &balanceService{
-		db:    dbx,
-		users: pgusers.New(dbx),
-		txns:  pgtransactions.New(dbx),
+// WithTimeouts bounds every query and every transaction issued by the
+// service.
+func WithTimeouts(t pgutils.Timeouts) Option {
+	return func(s *balanceService) { s.timeouts = t }
+}
+
+// New returns the service over database/sql (pgx stdlib driver).
+func New(dbx *sql.DB, opts ...Option) *balanceService {
+	s := newService(opts)
+
+	queryTimeout := pgutils.WithQueryTimeout(s.timeouts.Query)
+
+	s.tm = pgutils.NewTxManager(dbx)
+	s.users = pgusers.New(dbx, queryTimeout)
+	s.txns = pgtransactions.New(dbx, queryTimeout)
+	s.ledger = pgledger.New(dbx, queryTimeout)
+
+	if s.replica.db != nil {
+		s.wal = pgreplication.New(dbx, queryTimeout)
+		s.replica.users = pgusers.New(s.replica.db, queryTimeout)
+		s.replica.wal = pgreplication.New(s.replica.db, queryTimeout)
 	}
+
+	return s
 }
 
-// ProcessTransaction runs the full flow in a single DB transaction:
+// NewPool returns the service over a native pgxpool.
+func NewPool(pool *pgxpool.Pool, opts ...Option) *balanceService {
+	s := newService(opts)
+
+	queryTimeout := pgutils.WithQueryTimeout(s.timeouts.Query)
+
+	s.tm = pgxutils.NewTxManager(pool)
+	s.users = pgxusers.New(pool, queryTimeout)
+	s.txns = pgxtransactions.New(pool, queryTimeout)
+	s.ledger = pgxledger.New(pool, queryTimeout)
+
+	if s.replica.pool != nil {
+		s.wal = pgxreplication.New(pool, queryTimeout)
+		s.replica.users = pgxusers.New(s.replica.pool, queryTimeout)
+		s.replica.wal = pgxreplication.New(s.replica.pool, queryTimeout)
+	}
+
+	return s
+}
+
+// NewWithRepos returns the service over the given repos, for backends other
+// than Postgres. Transactions are processed step by step in tm.
+func NewWithRepos(
+	tm txn.Manager,
+	users users.Users,
+	txns transactions.Transactions,
+	opts ...Option,
+) *balanceService {
+	s := newService(opts)
+	s.tm = tm
+	s.users = users
+	s.txns = txns
+
+	return s
+}
+
+func newService(opts []Option) *balanceService {
+	s := &balanceService{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// ProcessTransaction applies a win/lose transaction exactly once per
+// transaction ID, never letting the balance go negative.
+func (s *balanceService) ProcessTransaction(
+	ctx context.Context,
+	transaction Transaction,
+) (_ WriteToken, retErr error) {
+	ctx, span := tracing.Start(ctx, tracerScope, "balance.ProcessTransaction", trace.WithAttributes(
+		attribute.Int64("user.id", int64(transaction.UserID)), //nolint:gosec
+		attribute.String("transaction.id", transaction.TransactionID),
+		attribute.String("transaction.source", string(transaction.Source)),
+		attribute.String("transaction.state", string(transaction.State)),
+	))
+	defer func() { tracing.End(span, retErr) }()
+
+	var (
+		newBalance money.Amount
+		err        error
+	)
+
+	if s.ledger != nil {
+		newBalance, err = s.applyLedger(ctx, transaction)
+	} else {
+		newBalance, err = s.applySteps(ctx, transaction)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("process transaction: %w", pgutils.MapTimeout(ctx, err))
+	}
+
+	slog.DebugContext(ctx, "transaction applied",
+		"state", transaction.State,
+		"amount", transaction.Amount,
+		"balance", newBalance,
+	)
+
+	return s.writeToken(ctx), nil
+}
+
+// applyLedger hands the whole transaction to the ledger as one statement and
+// returns the new balance.
+func (s *balanceService) applyLedger(ctx context.Context, transaction Transaction) (money.Amount, error) {
+	delta := transaction.Amount
+
+	switch transaction.State {
+	case TxWin:
+	case TxLose:
+		neg, err := delta.Neg()
+		if err != nil {
+			return 0, apperr.Invalid(apperr.CodeInvalidAmount, "amount", "out of range")
+		}
+
+		delta = neg
+	default:
+		return 0, apperr.Invalid(apperr.CodeInvalidState, "state", "must be win or lose")
+	}
+
+	ctx, cancel := s.timeouts.TxContext(ctx)
+	defer cancel()
+
+	var newBalance money.Amount
+
+	err := pgutils.Retry(ctx, txMaxAttempts, txBaseBackoff, func(ctx context.Context) error {
+		var err error
+
+		newBalance, err = s.ledger.Apply(ctx, transaction.TransactionID, transaction.UserID, delta)
+
+		return err //nolint:wrapcheck // wrapped below
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ledger apply: %w", err)
+	}
+
+	return newBalance, nil
+}
+
+// applySteps runs the full flow in a single SERIALIZABLE DB transaction:
 //
 // 1) Ensure user exists.
 // 2) Lock user row (FOR UPDATE).
 // 3) Apply effect via repo calls.
-// 4) Insert tx (unique-violation -> ErrDuplicateTransaction).
-func (s *balanceService) ProcessTransaction(ctx context.Context, transaction Transaction) error {
-	err := pgutils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+// 4) Insert tx (unique-violation -> apperr.ErrDuplicateTransaction).
+//
+// The callback only acts on what it reads inside the transaction, so it is
+// safe for WithTx to re-run it after a serialization failure. It returns the
+// new balance.
+func (s *balanceService) applySteps(ctx context.Context, transaction Transaction) (money.Amount, error) {
+	var newBalance money.Amount
+
+	err := s.tm.WithTx(ctx, func(ctx context.Context) error {
 		// 1) Ensure user exists
-		err := s.users.Exists(tx, transaction.UserID)
+		err := s.users.Exists(ctx, transaction.UserID)
 		if err != nil {
 			return fmt.Errorf("check user exists: %w", err)
 		}
 
 		// 2) Lock user row
-		balance, err := s.users.LockAndGetBalance(tx, transaction.UserID)
+		balance, err := s.users.LockAndGetBalance(ctx, transaction.UserID)
 		if err != nil {
 			return fmt.Errorf("lock and get balance: %w", err)
 		}
@@ -88,47 +271,75 @@ func (s *balanceService) ProcessTransaction(ctx context.Context, transaction Tra
 		// 3) Apply the effect
 		switch transaction.State {
 		case TxWin:
-			err = s.users.IncreaseBalance(tx, transaction.UserID, transaction.AmountMinor)
+			newBalance, err = balance.Add(transaction.Amount)
+			if err != nil {
+				return apperr.Invalid(apperr.CodeInvalidAmount, "amount", "balance would overflow")
+			}
+
+			err = s.users.IncreaseBalance(ctx, transaction.UserID, transaction.Amount)
 			if err != nil {
 				return fmt.Errorf("increase balance: %w", err)
 			}
 
 		case TxLose:
 			// pre-check against locked balance
-			if balance < transaction.AmountMinor {
-				return fmt.Errorf("pre-check decrease: %w", users.ErrInsufficientFunds)
+			if balance < transaction.Amount {
+				return fmt.Errorf("pre-check decrease: %w", apperr.ErrInsufficientFunds)
 			}
 
-			err = s.users.DecreaseBalance(tx, transaction.UserID, transaction.AmountMinor)
+			newBalance = balance - transaction.Amount
+
+			err = s.users.DecreaseBalance(ctx, transaction.UserID, transaction.Amount)
 			if err != nil {
 				return fmt.Errorf("decrease balance: %w", err)
 			}
 
 		default:
-			return fmt.Errorf("invalid state: %s", transaction.State)
+			return apperr.Invalid(apperr.CodeInvalidState, "state", "must be win or lose")
 		}
 
 		// 4) Insert transaction record
-		err = s.txns.Insert(tx, transaction.TransactionID, transaction.UserID)
+		err = s.txns.Insert(ctx, transaction.TransactionID, transaction.UserID)
 		if err != nil {
 			return fmt.Errorf("insert transaction: %w", err)
 		}
 
 		return nil
-	})
+	},
+		txn.WithIsolation(sql.LevelSerializable),
+		txn.WithRetry(txMaxAttempts, txBaseBackoff),
+		txn.WithTimeout(s.timeouts.Tx),
+	)
 	if err != nil {
-		return fmt.Errorf("process transaction: %w", err)
+		return 0, fmt.Errorf("with tx: %w", err)
 	}
 
-	return nil
+	return newBalance, nil
 }
 
 // GetBalance returns the user's balance (no locks; suitable for the GET endpoint).
-func (s *balanceService) GetBalance(ctx context.Context, userID uint64) (int64, error) {
-	balance, err := s.users.GetBalance(ctx, userID)
+// It reads from the replica when one is configured and has caught up with
+// after, and from the primary otherwise.
+func (s *balanceService) GetBalance(
+	ctx context.Context,
+	userID uint64,
+	after WriteToken,
+) (_ money.Amount, retErr error) {
+	ctx, span := tracing.Start(ctx, tracerScope, "balance.GetBalance", trace.WithAttributes(
+		attribute.Int64("user.id", int64(userID)), //nolint:gosec
+	))
+	defer func() { tracing.End(span, retErr) }()
+
+	if balance, ok := s.cachedBalance(ctx, userID, after); ok {
+		return balance, nil
+	}
+
+	balance, err := s.readBalance(ctx, userID, after)
 	if err != nil {
-		return 0, fmt.Errorf("get balance: %w", err)
+		return 0, fmt.Errorf("get balance: %w", pgutils.MapTimeout(ctx, err))
 	}
 
+	s.fillCache(ctx, userID, balance)
+
 	return balance, nil
 }
@@ -0,0 +1,127 @@
+package balance
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+)
+
+// BenchmarkProcessTransaction_HotUser hammers a single user with alternating
+// wins and losses from parallel goroutines, comparing the step-by-step flow
+// (4 queries inside BEGIN/COMMIT) with the single-statement ledger path, on
+// both the database/sql and the native pgxpool backend.
+//
+//	go test ./internal/services/balance -run '^$' -bench HotUser -cpu 8
+//
+// Reports throughput (tx/s) and p99 latency (p99-ms) next to ns/op.
+func BenchmarkProcessTransaction_HotUser(b *testing.B) {
+	modes := []struct {
+		name     string
+		driver   string
+		useSteps bool
+	}{
+		{"stdlib/steps", config.DriverStdlib, true},
+		{"stdlib/ledger", config.DriverStdlib, false},
+		{"pgxpool/steps", config.DriverPgxPool, true},
+		{"pgxpool/ledger", config.DriverPgxPool, false},
+	}
+
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			svc := newBenchService(b, mode.driver)
+			if mode.useSteps {
+				svc.ledger = nil
+			}
+
+			var (
+				seq       atomic.Int64
+				mu        sync.Mutex
+				latencies = make([]time.Duration, 0, b.N)
+			)
+
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				local := make([]time.Duration, 0, 128)
+
+				for pb.Next() {
+					n := seq.Add(1)
+
+					state := TxWin
+					if n%2 == 0 {
+						state = TxLose
+					}
+
+					start := time.Now()
+
+					_, err := svc.ProcessTransaction(b.Context(), Transaction{
+						TransactionID: fmt.Sprintf("bench-%d", n),
+						UserID:        1,
+						Source:        SourceGame,
+						State:         state,
+						Amount:        100,
+					})
+					if err != nil {
+						b.Errorf("process transaction: %v", err)
+
+						return
+					}
+
+					local = append(local, time.Since(start))
+				}
+
+				mu.Lock()
+				latencies = append(latencies, local...)
+				mu.Unlock()
+			})
+
+			b.StopTimer()
+
+			if len(latencies) == 0 {
+				return
+			}
+
+			slices.Sort(latencies)
+			p99 := latencies[(len(latencies)*99)/100]
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "tx/s")
+			b.ReportMetric(float64(p99.Microseconds())/1000, "p99-ms")
+		})
+	}
+}
+
+// newBenchService returns a service on a fresh test database holding one
+// wealthy user with id 1.
+func newBenchService(b *testing.B, driver string) *balanceService {
+	b.Helper()
+
+	const seed = `INSERT INTO users (id, balance) VALUES (1, 1000000000)`
+
+	if driver == config.DriverPgxPool {
+		pool, cleanup := pgtestutil.NewTestPool(b)
+		b.Cleanup(cleanup)
+
+		_, err := pool.Exec(b.Context(), seed)
+		if err != nil {
+			b.Fatalf("seed user: %v", err)
+		}
+
+		return NewPool(pool)
+	}
+
+	db, cleanup := pgtestutil.NewTestDB(b)
+	b.Cleanup(cleanup)
+
+	_, err := db.Exec(seed)
+	if err != nil {
+		b.Fatalf("seed user: %v", err)
+	}
+
+	return New(db)
+}
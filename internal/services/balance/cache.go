@@ -0,0 +1,82 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/infra/cache"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BalanceCache holds recently read or written balances by user ID.
+type BalanceCache = cache.Cache[uint64, money.Amount]
+
+// BalanceChangedChannel is the Postgres NOTIFY channel on which every
+// committed balance change is published (migration 000004).
+const BalanceChangedChannel = "balance_changed"
+
+var ErrInvalidNotification = errors.New("invalid balance_changed payload")
+
+// NewBalanceCache returns a cache for WithCache.
+func NewBalanceCache(maxSize int, ttl time.Duration) *BalanceCache {
+	return cache.New[uint64, money.Amount]("balance", maxSize, ttl)
+}
+
+// WithCache puts c in front of GetBalance. Commits reach it only through
+// ApplyBalanceChanged, this instance's included: notifications arrive in
+// commit order, so an older balance never replaces a newer one, which the
+// service storing its own result after commit could not guarantee.
+func WithCache(c *BalanceCache) Option {
+	return func(s *balanceService) { s.cache = c }
+}
+
+// ApplyBalanceChanged updates c from a balance_changed notification payload
+// of the form "<user id>:<balance in cents>".
+func ApplyBalanceChanged(ctx context.Context, c *BalanceCache, payload string) error {
+	rawID, rawBalance, ok := strings.Cut(payload, ":")
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrInvalidNotification, payload)
+	}
+
+	userID, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: user id: %w", ErrInvalidNotification, err)
+	}
+
+	cents, err := strconv.ParseInt(rawBalance, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: balance: %w", ErrInvalidNotification, err)
+	}
+
+	c.Set(ctx, userID, money.Amount(cents))
+
+	return nil
+}
+
+// cachedBalance answers from the cache unless the caller asked to read after
+// a specific write: the cache may not have seen that write's notification yet.
+func (s *balanceService) cachedBalance(ctx context.Context, userID uint64, after WriteToken) (money.Amount, bool) {
+	if s.cache == nil || after != "" {
+		return 0, false
+	}
+
+	balance, ok := s.cache.Get(ctx, userID)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", ok))
+
+	return balance, ok
+}
+
+// fillCache stores a balance read from the database. It never overwrites a
+// live entry: a commit or notification that landed while the read was in
+// flight is newer than what was read.
+func (s *balanceService) fillCache(ctx context.Context, userID uint64, balance money.Amount) {
+	if s.cache != nil {
+		s.cache.Add(ctx, userID, balance)
+	}
+}
@@ -0,0 +1,173 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+func TestGetBalance_Cache(t *testing.T) {
+	t.Parallel()
+
+	c := NewBalanceCache(10, time.Minute)
+	svc := &balanceService{users: fakeBalanceReader{balance: 100}, cache: c}
+
+	got, err := svc.GetBalance(t.Context(), 1, "")
+	if err != nil {
+		t.Fatalf("get balance: %v", err)
+	}
+
+	if got != 100 {
+		t.Fatalf("first read: want 100, got %s", got)
+	}
+
+	// The fill is served from now on even though the database moved on.
+	svc.users = fakeBalanceReader{balance: 70}
+
+	got, _ = svc.GetBalance(t.Context(), 1, "")
+	if got != 100 {
+		t.Fatalf("cached read: want 100, got %s", got)
+	}
+
+	// A write token bypasses the cache.
+	got, _ = svc.GetBalance(t.Context(), 1, "0/1")
+	if got != 70 {
+		t.Fatalf("read after token: want 70, got %s", got)
+	}
+
+	// The commit's notification replaces the entry.
+	err = ApplyBalanceChanged(t.Context(), c, "1:70")
+	if err != nil {
+		t.Fatalf("apply notification: %v", err)
+	}
+
+	got, _ = svc.GetBalance(t.Context(), 1, "")
+	if got != 70 {
+		t.Fatalf("read after commit: want 70, got %s", got)
+	}
+
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("stats: want 2 hits and 1 miss, got %+v", stats)
+	}
+}
+
+// notifyingLedger commits newBalance and, before Apply returns, delivers
+// the notifications in notify: those of its own commit and of any later one.
+type notifyingLedger struct {
+	cache      *BalanceCache
+	newBalance money.Amount
+	notify     []string
+}
+
+func (l notifyingLedger) Apply(ctx context.Context, _ string, _ uint64, _ money.Amount) (money.Amount, error) {
+	for _, payload := range l.notify {
+		err := ApplyBalanceChanged(ctx, l.cache, payload)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return l.newBalance, nil
+}
+
+// A later commit (here by another instance) can be notified before the
+// service gets back from its own commit; its own result is then the older
+// balance and must not replace the newer one.
+func TestProcessTransaction_CacheKeepsNewerBalance(t *testing.T) {
+	t.Parallel()
+
+	c := NewBalanceCache(10, time.Minute)
+	svc := &balanceService{
+		ledger: notifyingLedger{cache: c, newBalance: 150, notify: []string{"1:150", "1:200"}},
+		cache:  c,
+	}
+
+	_, err := svc.ProcessTransaction(t.Context(), Transaction{
+		TransactionID: "tx-1",
+		UserID:        1,
+		Source:        SourceGame,
+		State:         TxWin,
+		Amount:        50,
+	})
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	got, ok := c.Get(t.Context(), 1)
+	if !ok || got != 200 {
+		t.Fatalf("cached %s (%v), want the newer 200", got, ok)
+	}
+}
+
+// TestGetBalance_CacheSuspended covers the window in which the balance_changed
+// listener is disconnected: commits by other instances go unnoticed, so reads
+// must go to the database until the listener is back.
+func TestGetBalance_CacheSuspended(t *testing.T) {
+	t.Parallel()
+
+	c := NewBalanceCache(10, time.Minute)
+	svc := &balanceService{users: fakeBalanceReader{balance: 100}, cache: c}
+
+	_, _ = svc.GetBalance(t.Context(), 1, "")
+
+	// The listener drops, then another instance commits.
+	c.Suspend()
+
+	svc.users = fakeBalanceReader{balance: 70}
+
+	for range 2 {
+		got, _ := svc.GetBalance(t.Context(), 1, "")
+		if got != 70 {
+			t.Fatalf("read while disconnected: want 70, got %s", got)
+		}
+	}
+
+	// Another commit, still unnoticed; then the listener is back.
+	svc.users = fakeBalanceReader{balance: 40}
+
+	c.Resume()
+
+	got, _ := svc.GetBalance(t.Context(), 1, "")
+	if got != 40 {
+		t.Fatalf("read after reconnect: want 40, got %s", got)
+	}
+}
+
+func TestApplyBalanceChanged(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		payload string
+		wantErr bool
+		userID  uint64
+		want    money.Amount
+	}{
+		{payload: "7:1250", userID: 7, want: 1250},
+		{payload: "7:0", userID: 7, want: 0},
+		{payload: "7", wantErr: true},
+		{payload: "x:1", wantErr: true},
+		{payload: "7:1.5", wantErr: true},
+		{payload: "-1:5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c := NewBalanceCache(10, time.Minute)
+
+		err := ApplyBalanceChanged(t.Context(), c, tt.payload)
+		if tt.wantErr != errors.Is(err, ErrInvalidNotification) {
+			t.Fatalf("ApplyBalanceChanged(%q): err = %v, wantErr %v", tt.payload, err, tt.wantErr)
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		got, ok := c.Get(t.Context(), tt.userID)
+		if !ok || got != tt.want {
+			t.Fatalf("ApplyBalanceChanged(%q): cached %s (%v), want %s", tt.payload, got, ok, tt.want)
+		}
+	}
+}
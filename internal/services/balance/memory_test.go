@@ -0,0 +1,79 @@
+package balance
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/infra/memtx"
+	memtransactions "github.com/fastprodman/EntainHW/internal/repos/transactions/memory"
+	memusers "github.com/fastprodman/EntainHW/internal/repos/users/memory"
+)
+
+// The step-by-step flow over the in-memory repos: concurrent loses never
+// overdraw and every ID is applied once.
+func TestProcessTransaction_Memory(t *testing.T) {
+	t.Parallel()
+
+	usersRepo := memusers.New()
+	usersRepo.Put(1, 1000)
+
+	svc := NewWithRepos(memtx.NewTxManager(), usersRepo, memtransactions.New(usersRepo))
+
+	const workers = 40
+
+	var (
+		wg                 sync.WaitGroup
+		mu                 sync.Mutex
+		applied, overdrawn int
+	)
+
+	for i := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Every ID is sent twice.
+			for range 2 {
+				_, err := svc.ProcessTransaction(t.Context(), Transaction{
+					TransactionID: fmt.Sprintf("tx-%d", i),
+					UserID:        1,
+					Source:        SourceGame,
+					State:         TxLose,
+					Amount:        100,
+				})
+
+				mu.Lock()
+
+				switch {
+				case err == nil:
+					applied++
+				case errors.Is(err, apperr.ErrInsufficientFunds):
+					overdrawn++
+				case !errors.Is(err, apperr.ErrDuplicateTransaction):
+					t.Errorf("process: %v", err)
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if applied != 10 {
+		t.Fatalf("applied: want 10, got %d (overdrawn %d)", applied, overdrawn)
+	}
+
+	balance, err := svc.GetBalance(t.Context(), 1, "")
+	if err != nil {
+		t.Fatalf("get balance: %v", err)
+	}
+
+	if balance != 0 {
+		t.Fatalf("balance: want 0, got %s", balance)
+	}
+}
@@ -0,0 +1,118 @@
+package balance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"regexp"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/repos/replication"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/pkg/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WriteToken identifies a point in the primary's write history (a Postgres
+// WAL position). Reads given a token never observe an older state.
+type WriteToken string
+
+// replicaStore is the optional read replica. db or pool is set by the
+// options and wired into users/wal by New or NewPool.
+type replicaStore struct {
+	db    *sql.DB
+	pool  *pgxpool.Pool
+	users users.Users
+	wal   replication.Tracker
+}
+
+var (
+	ErrInvalidWriteToken = errors.New("invalid write token")
+
+	// writeTokenPattern is the textual pg_lsn form, e.g. 16/B374D848.
+	writeTokenPattern = regexp.MustCompile(`^[0-9A-Fa-f]{1,8}/[0-9A-Fa-f]{1,8}$`)
+)
+
+// WithReplicaDB routes balance reads to a read replica; for New.
+func WithReplicaDB(db *sql.DB) Option {
+	return func(s *balanceService) { s.replica.db = db }
+}
+
+// WithReplicaPool routes balance reads to a read replica; for NewPool.
+func WithReplicaPool(pool *pgxpool.Pool) Option {
+	return func(s *balanceService) { s.replica.pool = pool }
+}
+
+// ParseWriteToken validates a token received from a client. An empty string
+// is the empty token.
+func ParseWriteToken(s string) (WriteToken, error) {
+	if s != "" && !writeTokenPattern.MatchString(s) {
+		return "", ErrInvalidWriteToken
+	}
+
+	return WriteToken(s), nil
+}
+
+// readBalance prefers the replica, falling back to the primary when there is
+// none, when it lags behind after, or when it fails.
+func (s *balanceService) readBalance(ctx context.Context, userID uint64, after WriteToken) (money.Amount, error) {
+	span := trace.SpanFromContext(ctx)
+
+	if s.replica.users == nil || !s.replicaCaughtUp(ctx, after) {
+		span.SetAttributes(attribute.Bool("db.replica", false))
+
+		return s.users.GetBalance(ctx, userID) //nolint:wrapcheck // wrapped by GetBalance
+	}
+
+	balance, err := s.replica.users.GetBalance(ctx, userID)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("db.replica", true))
+
+		return balance, nil
+	}
+
+	// A user missing on the replica may simply not have been replicated yet.
+	if !errors.Is(err, apperr.ErrUserNotFound) {
+		slog.WarnContext(ctx, "replica read failed, using primary", "error", err)
+	}
+
+	span.SetAttributes(attribute.Bool("db.replica", false))
+
+	return s.users.GetBalance(ctx, userID) //nolint:wrapcheck // wrapped by GetBalance
+}
+
+func (s *balanceService) replicaCaughtUp(ctx context.Context, after WriteToken) bool {
+	if after == "" {
+		return true
+	}
+
+	caughtUp, err := s.replica.wal.CaughtUp(ctx, string(after))
+	if err != nil {
+		slog.WarnContext(ctx, "replica lag check failed, using primary", "error", err)
+
+		return false
+	}
+
+	return caughtUp
+}
+
+// writeToken returns a token covering the write just committed, or "" when
+// no replica is configured. Failing to get one does not fail the write: the
+// client merely loses read-your-writes for that request.
+func (s *balanceService) writeToken(ctx context.Context) WriteToken {
+	if s.wal == nil {
+		return ""
+	}
+
+	token, err := s.wal.WriteToken(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "write token unavailable", "error", err)
+
+		return ""
+	}
+
+	return WriteToken(token)
+}
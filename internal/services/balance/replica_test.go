@@ -0,0 +1,125 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/apperr"
+	"github.com/fastprodman/EntainHW/internal/repos/users"
+	"github.com/fastprodman/EntainHW/pkg/money"
+)
+
+// fakeBalanceReader answers GetBalance with a fixed balance or error; the
+// other users.Users methods are not used by readBalance.
+type fakeBalanceReader struct {
+	users.Users
+
+	balance money.Amount
+	err     error
+}
+
+type fakeTracker struct {
+	caughtUp bool
+	err      error
+}
+
+func (f fakeBalanceReader) GetBalance(context.Context, uint64) (money.Amount, error) {
+	return f.balance, f.err
+}
+
+func (f fakeTracker) WriteToken(context.Context) (string, error) { return "0/1", f.err }
+
+func (f fakeTracker) CaughtUp(context.Context, string) (bool, error) { return f.caughtUp, f.err }
+
+func TestReadBalance_Routing(t *testing.T) {
+	t.Parallel()
+
+	const (
+		primaryBalance money.Amount = 100
+		replicaBalance money.Amount = 50
+	)
+
+	errDown := errors.New("replica down")
+
+	tests := []struct {
+		name       string
+		noReplica  bool
+		replicaErr error
+		tracker    fakeTracker
+		after      WriteToken
+		want       money.Amount
+	}{
+		{name: "no_replica_reads_primary", noReplica: true, want: primaryBalance},
+		{name: "no_token_reads_replica", want: replicaBalance},
+		{name: "caught_up_reads_replica", after: "0/1", tracker: fakeTracker{caughtUp: true}, want: replicaBalance},
+		{name: "lagging_reads_primary", after: "0/1", tracker: fakeTracker{caughtUp: false}, want: primaryBalance},
+		{name: "lag_check_error_reads_primary", after: "0/1", tracker: fakeTracker{err: errDown}, want: primaryBalance},
+		{name: "replica_error_reads_primary", replicaErr: errDown, want: primaryBalance},
+		{name: "missing_on_replica_reads_primary", replicaErr: apperr.ErrUserNotFound, want: primaryBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &balanceService{users: fakeBalanceReader{balance: primaryBalance}}
+			if !tt.noReplica {
+				svc.replica.users = fakeBalanceReader{balance: replicaBalance, err: tt.replicaErr}
+				svc.replica.wal = tt.tracker
+			}
+
+			got, err := svc.GetBalance(t.Context(), 1, tt.after)
+			if err != nil {
+				t.Fatalf("get balance: %v", err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("balance: want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteToken(t *testing.T) {
+	t.Parallel()
+
+	if got := (&balanceService{}).writeToken(t.Context()); got != "" {
+		t.Fatalf("without replica: want empty token, got %q", got)
+	}
+
+	svc := &balanceService{wal: fakeTracker{}}
+	if got := svc.writeToken(t.Context()); got != "0/1" {
+		t.Fatalf("want token 0/1, got %q", got)
+	}
+
+	svc.wal = fakeTracker{err: errors.New("boom")}
+	if got := svc.writeToken(t.Context()); got != "" {
+		t.Fatalf("failed lookup: want empty token, got %q", got)
+	}
+}
+
+func TestParseWriteToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: ""},
+		{in: "0/16B3748"},
+		{in: "16/b374d848"},
+		{in: "FFFFFFFF/FFFFFFFF"},
+		{in: "16", wantErr: true},
+		{in: "16/", wantErr: true},
+		{in: "1FFFFFFFF/0", wantErr: true},
+		{in: "0/16B3748; DROP TABLE users", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseWriteToken(tt.in)
+		if tt.wantErr != errors.Is(err, ErrInvalidWriteToken) {
+			t.Fatalf("ParseWriteToken(%q): err = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package balance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/internal/infra/pgtestutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+//nolint:paralleltest // swaps the global TracerProvider
+func TestProcessTransaction_SpanTree(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+
+		otel.SetTracerProvider(prev)
+	})
+
+	tests := []struct {
+		name     string
+		useSteps bool
+		parentOf map[string]string
+	}{
+		{
+			name: "ledger",
+			parentOf: map[string]string{
+				"ledger.Apply": "balance.ProcessTransaction",
+			},
+		},
+		{
+			name:     "steps",
+			useSteps: true,
+			parentOf: map[string]string{
+				"pgutils.WithTx":          "balance.ProcessTransaction",
+				"users.Exists":            "pgutils.WithTx",
+				"users.LockAndGetBalance": "pgutils.WithTx",
+				"users.IncreaseBalance":   "pgutils.WithTx",
+				"transactions.Insert":     "pgutils.WithTx",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp.Reset()
+
+			db, cleanup := pgtestutil.NewTestDB(t)
+			defer cleanup()
+
+			_, err := db.Exec(`INSERT INTO users (id, balance) VALUES ($1, $2)`, 1, 0)
+			if err != nil {
+				t.Fatalf("seed user: %v", err)
+			}
+
+			svc := New(db)
+			if tt.useSteps {
+				svc.ledger = nil
+			}
+
+			_, err = svc.ProcessTransaction(t.Context(), Transaction{
+				TransactionID: "tx-span-tree",
+				UserID:        1,
+				Source:        SourceGame,
+				State:         TxWin,
+				Amount:        100,
+			})
+			if err != nil {
+				t.Fatalf("process transaction: %v", err)
+			}
+
+			assertSpanTree(t, exp, tt.parentOf)
+		})
+	}
+}
+
+func assertSpanTree(t *testing.T, exp *tracetest.InMemoryExporter, parentOf map[string]string) {
+	t.Helper()
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range exp.GetSpans().Snapshots() {
+		byName[s.Name()] = s
+	}
+
+	root, ok := byName["balance.ProcessTransaction"]
+	if !ok {
+		t.Fatalf("missing root span; got %v", names(byName))
+	}
+
+	for child, parent := range parentOf {
+		cs, ok := byName[child]
+		if !ok {
+			t.Fatalf("missing span %q; got %v", child, names(byName))
+		}
+
+		if cs.Parent().SpanID() != byName[parent].SpanContext().SpanID() {
+			t.Fatalf("span %q: want parent %q", child, parent)
+		}
+
+		if cs.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Fatalf("span %q is not part of the root trace", child)
+		}
+	}
+}
+
+func names(m map[string]sdktrace.ReadOnlySpan) []string {
+	out := make([]string, 0, len(m))
+	for n := range m {
+		out = append(out, n)
+	}
+
+	return out
+}
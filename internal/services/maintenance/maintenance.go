@@ -0,0 +1,101 @@
+// Package maintenance keeps the partitioned transactions table in shape:
+// future partitions exist before rows need them, partitions past the
+// idempotency window are archived and transaction ids past the key retention
+// are forgotten.
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/infra/pgutils"
+	"github.com/fastprodman/EntainHW/internal/infra/tracing"
+	"github.com/fastprodman/EntainHW/internal/repos/partitions"
+	pgpartitions "github.com/fastprodman/EntainHW/internal/repos/partitions/postgres"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+)
+
+const tracerScope = "github.com/fastprodman/EntainHW/internal/services/maintenance"
+
+// Maintainer runs one maintenance pass.
+type Maintainer interface {
+	Run(ctx context.Context) (Result, error)
+}
+
+// Result lists the partitions a pass created and archived and counts the
+// transaction ids it pruned.
+type Result struct {
+	Created    []string
+	Archived   []string
+	PrunedKeys int64
+}
+
+type maintainer struct {
+	tm         txn.Manager
+	partitions partitions.Partitions
+	cfg        config.PartitionConfig
+	now        func() time.Time
+}
+
+var _ Maintainer = (*maintainer)(nil)
+
+func New(db *sql.DB, cfg *config.PartitionConfig) *maintainer {
+	return &maintainer{
+		tm:         pgutils.NewTxManager(db),
+		partitions: pgpartitions.New(db),
+		cfg:        *cfg,
+		now:        time.Now,
+	}
+}
+
+// Run creates the partitions for the current month and the configured months
+// ahead, archives those that ended before the idempotency window and prunes
+// the transaction ids older than the key retention. It
+// holds an advisory lock for the whole pass, so concurrent runs queue up
+// instead of racing on the same DDL.
+func (m *maintainer) Run(ctx context.Context) (res Result, err error) {
+	ctx, span := tracing.Start(ctx, tracerScope, "maintenance.Run")
+	defer func() { tracing.End(span, err) }()
+
+	now := m.now()
+
+	err = m.tm.WithTx(ctx, func(ctx context.Context) error {
+		err := m.partitions.Lock(ctx)
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		res.Created, err = m.partitions.Create(ctx, now, now.AddDate(0, m.cfg.MonthsAhead, 0))
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped below
+		}
+
+		if m.cfg.IdempotencyWindow > 0 {
+			res.Archived, err = m.partitions.Archive(ctx, now.Add(-m.cfg.IdempotencyWindow))
+			if err != nil {
+				return err //nolint:wrapcheck // wrapped below
+			}
+		}
+
+		if m.cfg.KeyRetention > 0 {
+			res.PrunedKeys, err = m.partitions.PruneKeys(ctx, now.Add(-m.cfg.KeyRetention))
+		}
+
+		return err //nolint:wrapcheck // wrapped below
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("partition maintenance: %w", err)
+	}
+
+	slog.InfoContext(ctx, "partition maintenance done",
+		"created", res.Created,
+		"archived", res.Archived,
+		"pruned_keys", res.PrunedKeys,
+	)
+
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/internal/config"
+	"github.com/fastprodman/EntainHW/internal/repos/txn"
+)
+
+type inlineTx struct{}
+
+// fakePartitions records the bounds it was called with.
+type fakePartitions struct {
+	locked        bool
+	from, to      time.Time
+	archiveBefore time.Time
+	archiveCalled bool
+	pruneBefore   time.Time
+	pruneCalled   bool
+}
+
+func (inlineTx) WithTx(ctx context.Context, fn func(context.Context) error, _ ...txn.Option) error {
+	return fn(ctx)
+}
+
+func (f *fakePartitions) Lock(context.Context) error {
+	f.locked = true
+
+	return nil
+}
+
+func (f *fakePartitions) Create(_ context.Context, from, to time.Time) ([]string, error) {
+	f.from, f.to = from, to
+
+	return []string{"created"}, nil
+}
+
+func (f *fakePartitions) Archive(_ context.Context, before time.Time) ([]string, error) {
+	f.archiveBefore, f.archiveCalled = before, true
+
+	return []string{"archived"}, nil
+}
+
+func (f *fakePartitions) PruneKeys(_ context.Context, before time.Time) (int64, error) {
+	f.pruneBefore, f.pruneCalled = before, true
+
+	return 7, nil
+}
+
+func TestRun_Bounds(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		cfg         config.PartitionConfig
+		wantTo      time.Time
+		wantArchive bool
+		wantBefore  time.Time
+		wantPrune   bool
+		wantPruneTo time.Time
+	}{
+		{
+			name:        "window_archives",
+			cfg:         config.PartitionConfig{MonthsAhead: 3, IdempotencyWindow: 90 * 24 * time.Hour},
+			wantTo:      time.Date(2025, time.June, 10, 12, 0, 0, 0, time.UTC),
+			wantArchive: true,
+			wantBefore:  time.Date(2024, time.December, 10, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "retention_prunes_keys",
+			cfg: config.PartitionConfig{
+				MonthsAhead:       3,
+				IdempotencyWindow: 90 * 24 * time.Hour,
+				KeyRetention:      365 * 24 * time.Hour,
+			},
+			wantTo:      time.Date(2025, time.June, 10, 12, 0, 0, 0, time.UTC),
+			wantArchive: true,
+			wantBefore:  time.Date(2024, time.December, 10, 12, 0, 0, 0, time.UTC),
+			wantPrune:   true,
+			wantPruneTo: time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "zero_window_keeps_everything",
+			cfg:    config.PartitionConfig{MonthsAhead: 1},
+			wantTo: time.Date(2025, time.April, 10, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parts := &fakePartitions{}
+			m := &maintainer{tm: inlineTx{}, partitions: parts, cfg: tt.cfg, now: func() time.Time { return now }}
+
+			res, err := m.Run(t.Context())
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			if !parts.locked {
+				t.Fatal("run must take the maintenance lock")
+			}
+
+			if !parts.from.Equal(now) || !parts.to.Equal(tt.wantTo) {
+				t.Fatalf("create range: want [%s, %s], got [%s, %s]", now, tt.wantTo, parts.from, parts.to)
+			}
+
+			if parts.archiveCalled != tt.wantArchive {
+				t.Fatalf("archive called: want %v, got %v", tt.wantArchive, parts.archiveCalled)
+			}
+
+			if tt.wantArchive && !parts.archiveBefore.Equal(tt.wantBefore) {
+				t.Fatalf("archive cutoff: want %s, got %s", tt.wantBefore, parts.archiveBefore)
+			}
+
+			if parts.pruneCalled != tt.wantPrune {
+				t.Fatalf("prune called: want %v, got %v", tt.wantPrune, parts.pruneCalled)
+			}
+
+			if tt.wantPrune && !parts.pruneBefore.Equal(tt.wantPruneTo) {
+				t.Fatalf("prune cutoff: want %s, got %s", tt.wantPruneTo, parts.pruneBefore)
+			}
+
+			if (res.PrunedKeys != 0) != tt.wantPrune {
+				t.Fatalf("pruned keys: %d, want pruning %v", res.PrunedKeys, tt.wantPrune)
+			}
+
+			if len(res.Created) != 1 || (len(res.Archived) != 0) != tt.wantArchive {
+				t.Fatalf("unexpected result %+v", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package envconf
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes written with an optional unit: decimal
+// (KB, MB, GB, TB), binary (KiB, MiB, GiB, TiB) or B. Units are
+// case-insensitive and fractions are allowed, e.g. "10MB", "1.5GiB", "512".
+type ByteSize uint64
+
+// maxFracDigits is the longest fraction whose scale, 10^n, fits a uint64.
+const maxFracDigits = 19
+
+var errByteSize = errors.New("invalid byte size")
+
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	mult, ok := byteUnits[unit]
+	if !ok {
+		return fmt.Errorf("%w: %q", errByteSize, s)
+	}
+
+	// Exact decimal arithmetic: "1.5" is 15 scaled down by 10^1.
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole+frac == "" {
+		return fmt.Errorf("%w: %q", errByteSize, s)
+	}
+
+	if len(frac) > maxFracDigits {
+		return fmt.Errorf("%w: %q has too many decimal places", errByteSize, s)
+	}
+
+	digits, err := strconv.ParseUint(whole+frac, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errByteSize, s)
+	}
+
+	hi, lo := bits.Mul64(digits, mult)
+	if hi != 0 {
+		return fmt.Errorf("%w: %q overflows", errByteSize, s)
+	}
+
+	scale := uint64(1)
+	for range frac {
+		scale *= 10
+	}
+
+	if lo%scale != 0 {
+		return fmt.Errorf("%w: %q is not a whole number of bytes", errByteSize, s)
+	}
+
+	*b = ByteSize(lo / scale)
+
+	return nil
+}
+
+// String formats b with the largest binary unit that divides it exactly.
+func (b ByteSize) String() string {
+	units := []struct {
+		name string
+		size ByteSize
+	}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}}
+
+	for _, u := range units {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
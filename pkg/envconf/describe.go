@@ -0,0 +1,325 @@
+package envconf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Var describes one variable a config struct accepts.
+type Var struct {
+	Field string
+	Env   string
+	Type  string
+	// Default is the `default` tag; HasDefault tells an empty default from
+	// none.
+	Default    string
+	HasDefault bool
+	// Required is true when the variable must be set: it has no default and
+	// is not `required:"false"`.
+	Required bool
+	// Secret is the `secret:"true"` tag; Dump redacts such values.
+	Secret bool
+	// Reloadable is the `reload:"true"` tag; see Reload.
+	Reloadable  bool
+	Description string
+}
+
+// Setting is one variable and its effective value, formatted for display.
+type Setting struct {
+	Env   string
+	Value string
+}
+
+// Settings is the effective configuration returned by Dump.
+type Settings []Setting
+
+// Redacted replaces the values of secret fields in Dump.
+const Redacted = "[REDACTED]"
+
+// Describe lists the variables the struct cfg points to accepts, in
+// declaration order, with the `desc` tag as Description.
+func Describe(cfg any) ([]Var, error) {
+	v, err := structValue(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		vars []Var
+		errs []error
+	)
+
+	for _, f := range walk(v) {
+		s, secret, err := describeSpec(f)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		reloadable, err := boolTag(f, "reload")
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		vars = append(vars, Var{
+			Field:       f.name,
+			Env:         f.env,
+			Type:        f.sf.Type.String(),
+			Default:     s.def,
+			HasDefault:  s.hasDefault,
+			Required:    s.required && !s.hasDefault,
+			Secret:      secret,
+			Reloadable:  reloadable,
+			Description: f.sf.Tag.Get("desc"),
+		})
+	}
+
+	return vars, errors.Join(errs...)
+}
+
+// Dump returns the current values of the fields of the struct cfg points
+// to, typically right after Load, with non-empty secret values replaced by
+// Redacted.
+func Dump(cfg any) (Settings, error) {
+	v, err := structValue(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out  Settings
+		errs []error
+	)
+
+	for _, f := range walk(v) {
+		s, secret, err := describeSpec(f)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		val := formatValue(f.v, s.format)
+		if secret && val != "" {
+			val = Redacted
+		}
+
+		out = append(out, Setting{Env: f.env, Value: val})
+	}
+
+	return out, errors.Join(errs...)
+}
+
+func describeSpec(f field) (spec, bool, error) {
+	s, err := parseSpec(f.sf)
+	if err != nil {
+		return spec{}, false, fmt.Errorf("%w: field %q: %w", ErrInvalidTag, f.name, err)
+	}
+
+	secret, err := boolTag(f, "secret")
+	if err != nil {
+		return spec{}, false, err
+	}
+
+	return s, secret, nil
+}
+
+// boolTag parses the boolean tag key of f; a missing tag is false.
+func boolTag(f field, key string) (bool, error) {
+	tag, ok := f.sf.Tag.Lookup(key)
+	if !ok {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(tag)
+	if err != nil {
+		return false, fmt.Errorf("%w: field %q: %s: %w", ErrInvalidTag, f.name, key, err)
+	}
+
+	return b, nil
+}
+
+// formatValue renders fv the way Load would parse it back.
+func formatValue(fv reflect.Value, f format) string {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return ""
+		}
+
+		fv = fv.Elem()
+	}
+
+	switch fv.Type() {
+	case timeType:
+		t, _ := fv.Interface().(time.Time) //nolint:forcetypeassert
+
+		return t.Format(f.layout)
+	case urlType:
+		u, _ := fv.Interface().(url.URL) //nolint:forcetypeassert
+
+		return u.String()
+	}
+
+	if s, ok := fv.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	switch fv.Kind() {
+	case reflect.Slice:
+		parts := make([]string, fv.Len())
+		for i := range parts {
+			parts[i] = formatValue(fv.Index(i), f)
+		}
+
+		return strings.Join(parts, f.sep)
+	case reflect.Map:
+		parts := make([]string, 0, fv.Len())
+
+		iter := fv.MapRange()
+		for iter.Next() {
+			parts = append(parts, iter.Key().String()+f.kvSep+formatValue(iter.Value(), f))
+		}
+
+		slices.Sort(parts)
+
+		return strings.Join(parts, f.sep)
+	default:
+		return fmt.Sprint(fv.Interface())
+	}
+}
+
+// String formats s as one ENV=value line per setting.
+func (s Settings) String() string {
+	var b strings.Builder
+
+	for _, st := range s {
+		b.WriteString(st.Env)
+		b.WriteByte('=')
+		b.WriteString(st.Value)
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer as a group of ENV: value attributes.
+func (s Settings) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(s))
+	for _, st := range s {
+		attrs = append(attrs, slog.String(st.Env, st.Value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// WriteDotEnv writes vars as a .env example: each variable with its
+// description and type as a comment, set to its default. Required and
+// secret variables are left empty.
+func WriteDotEnv(w io.Writer, vars []Var) error {
+	var b strings.Builder
+
+	for i, v := range vars {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+
+		if v.Description != "" {
+			b.WriteString("# " + v.Description + "\n")
+		}
+
+		b.WriteString("# " + v.Type + qualifiers(v) + "\n")
+
+		val := v.Default
+		if v.Secret {
+			val = ""
+		}
+
+		b.WriteString(v.Env + "=" + dotEnvQuote(val) + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("write .env: %w", err)
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes vars as a Markdown table.
+func WriteMarkdown(w io.Writer, vars []Var) error {
+	var b strings.Builder
+
+	b.WriteString("| Variable | Type | Default | Description |\n")
+	b.WriteString("| -------- | ---- | ------- | ----------- |\n")
+
+	for _, v := range vars {
+		def := "_required_"
+
+		switch {
+		case v.HasDefault && v.Default != "":
+			def = "`" + v.Default + "`"
+		case !v.Required:
+			def = "_empty_"
+		}
+
+		desc := v.Description
+		if v.Secret {
+			desc = strings.TrimSpace(desc + " Secret: redacted in logs.")
+		}
+
+		if v.Reloadable {
+			desc = strings.TrimSpace(desc + " Reloadable without a restart.")
+		}
+
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s |\n", v.Env, v.Type, def, markdownEscape(desc))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+
+	return nil
+}
+
+func qualifiers(v Var) string {
+	var q []string
+
+	if v.Required {
+		q = append(q, "required")
+	}
+
+	if v.Secret {
+		q = append(q, "secret")
+	}
+
+	if len(q) == 0 {
+		return ""
+	}
+
+	return ", " + strings.Join(q, ", ")
+}
+
+// dotEnvQuote double-quotes values a bare .env value would misread.
+func dotEnvQuote(s string) string {
+	if strings.ContainsAny(s, " #\"'\t") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,165 @@
+package envconf_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+type describedDB struct {
+	DSN   string   `env:"DSN" secret:"true" desc:"Connection string."`
+	Hosts []string `env:"HOSTS" default:"a,b" desc:"Hosts | replicas."`
+}
+
+type describedConfig struct {
+	Port    int           `env:"D_PORT" default:"8080" desc:"Listen port."`
+	Timeout time.Duration `env:"D_TIMEOUT" default:"5s"`
+	Note    string        `env:"D_NOTE" required:"false" desc:"Free text."`
+	DB      *describedDB  `envPrefix:"D_DB_"`
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	vars, err := envconf.Describe(new(describedConfig))
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+
+	want := []envconf.Var{
+		{Field: "Port", Env: "D_PORT", Type: "int", Default: "8080", HasDefault: true, Description: "Listen port."},
+		{Field: "Timeout", Env: "D_TIMEOUT", Type: "time.Duration", Default: "5s", HasDefault: true},
+		{Field: "Note", Env: "D_NOTE", Type: "string", Description: "Free text."},
+		{Field: "DB.DSN", Env: "D_DB_DSN", Type: "string", Required: true, Secret: true, Description: "Connection string."},
+		{
+			Field: "DB.Hosts", Env: "D_DB_HOSTS", Type: "[]string", Default: "a,b", HasDefault: true,
+			Description: "Hosts | replicas.",
+		},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("Describe =\n%+v\nwant\n%+v", vars, want)
+	}
+}
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	cfg := &describedConfig{
+		Port:    9090,
+		Timeout: 1500 * time.Millisecond,
+		DB:      &describedDB{DSN: "postgres://u:p@h/db", Hosts: []string{"x", "y"}},
+	}
+
+	settings, err := envconf.Dump(cfg)
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+
+	want := envconf.Settings{
+		{Env: "D_PORT", Value: "9090"},
+		{Env: "D_TIMEOUT", Value: "1.5s"},
+		{Env: "D_NOTE", Value: ""},
+		{Env: "D_DB_DSN", Value: envconf.Redacted},
+		{Env: "D_DB_HOSTS", Value: "x,y"},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("Dump = %+v, want %+v", settings, want)
+	}
+
+	if strings.Contains(settings.String(), "postgres://") {
+		t.Errorf("String() leaks the secret:\n%s", settings)
+	}
+
+	cfg.DB.DSN = ""
+
+	settings, err = envconf.Dump(cfg)
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+
+	if got := settings[3].Value; got != "" {
+		t.Errorf("empty secret dumped as %q, want empty", got)
+	}
+}
+
+func TestDumpInvalidTag(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Key string `env:"KEY" secret:"maybe"`
+	}
+
+	_, err := envconf.Dump(&cfg)
+	if err == nil {
+		t.Fatal("Dump: want error for an invalid secret tag")
+	}
+}
+
+func TestWriteDotEnv(t *testing.T) {
+	t.Parallel()
+
+	vars, err := envconf.Describe(new(describedConfig))
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+
+	var b strings.Builder
+
+	err = envconf.WriteDotEnv(&b, vars)
+	if err != nil {
+		t.Fatalf("WriteDotEnv: %v", err)
+	}
+
+	want := `# Listen port.
+# int
+D_PORT=8080
+
+# time.Duration
+D_TIMEOUT=5s
+
+# Free text.
+# string
+D_NOTE=
+
+# Connection string.
+# string, required, secret
+D_DB_DSN=
+
+# Hosts | replicas.
+# []string
+D_DB_HOSTS=a,b
+`
+	if b.String() != want {
+		t.Errorf("WriteDotEnv =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	vars, err := envconf.Describe(new(describedConfig))
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+
+	var b strings.Builder
+
+	err = envconf.WriteMarkdown(&b, vars)
+	if err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+
+	want := "| Variable | Type | Default | Description |\n" +
+		"| -------- | ---- | ------- | ----------- |\n" +
+		"| `D_PORT` | `int` | `8080` | Listen port. |\n" +
+		"| `D_TIMEOUT` | `time.Duration` | `5s` |  |\n" +
+		"| `D_NOTE` | `string` | _empty_ | Free text. |\n" +
+		"| `D_DB_DSN` | `string` | _required_ | Connection string. Secret: redacted in logs. |\n" +
+		"| `D_DB_HOSTS` | `[]string` | `a,b` | Hosts \\| replicas. |\n"
+	if b.String() != want {
+		t.Errorf("WriteMarkdown =\n%s\nwant\n%s", b.String(), want)
+	}
+}
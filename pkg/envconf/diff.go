@@ -0,0 +1,121 @@
+package envconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Change is one variable whose value differs between two loads of a config.
+// Old and New are formatted as by Dump, secrets redacted.
+type Change struct {
+	Field string
+	Env   string
+	Old   string
+	New   string
+	// Applied is false when the field is not tagged `reload:"true"` and the
+	// change was reverted.
+	Applied bool
+}
+
+// Reload prepares next, a config freshly loaded into the same struct type as
+// cur, to replace cur while the program runs. Fields tagged `reload:"true"`
+// keep their new value unless their variable is listed in keep; every other
+// field that changed is reset to its value in cur, since the program only
+// reads it at startup. Reload returns every change, applied or not, in
+// declaration order. cur is not modified.
+func Reload(cur, next any, keep ...string) ([]Change, error) {
+	cv, err := structValue(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	nv, err := structValue(next)
+	if err != nil {
+		return nil, err
+	}
+
+	if cv.Type() != nv.Type() {
+		return nil, fmt.Errorf("reload: %s and %s differ", cv.Type(), nv.Type())
+	}
+
+	// Both walks allocate the same nil nested structs, so the fields pair up.
+	// cur's are allocated in a copy.
+	curFields, nextFields := walk(detached(cv)), walk(nv)
+
+	var (
+		changes []Change
+		errs    []error
+	)
+
+	for i, nf := range nextFields {
+		cf := curFields[i]
+
+		if reflect.DeepEqual(cf.v.Interface(), nf.v.Interface()) {
+			continue
+		}
+
+		s, secret, err := describeSpec(nf)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		reloadable, err := boolTag(nf, "reload")
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		reloadable = reloadable && !slices.Contains(keep, nf.env)
+
+		c := Change{
+			Field:   nf.name,
+			Env:     nf.env,
+			Old:     formatValue(cf.v, s.format),
+			New:     formatValue(nf.v, s.format),
+			Applied: reloadable,
+		}
+		if secret {
+			c.Old, c.New = Redacted, Redacted
+		}
+
+		if !reloadable {
+			nf.v.Set(cf.v)
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// detached returns a copy of the config struct v that walk can fill in
+// without touching v: the nested structs walk recurses into are copied too.
+func detached(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	for i := range c.NumField() {
+		sf := v.Type().Field(i)
+		fv := c.Field(i)
+
+		if tag := sf.Tag.Get("env"); !sf.IsExported() || (tag != "" && tag != "-") {
+			continue
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			fv.Set(detached(fv))
+		case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct && !fv.IsNil():
+			p := reflect.New(fv.Type().Elem())
+			p.Elem().Set(detached(fv.Elem()))
+			fv.Set(p)
+		}
+	}
+
+	return c
+}
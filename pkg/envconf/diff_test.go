@@ -0,0 +1,102 @@
+package envconf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+type reloadDB struct {
+	DSN      string `env:"R_DSN" secret:"true"`
+	MaxConns int    `env:"R_MAX_CONNS" reload:"true"`
+}
+
+type reloadConfig struct {
+	Port  int    `env:"R_PORT"`
+	Level string `env:"R_LEVEL" reload:"true"`
+	Name  string `env:"R_NAME"`
+	DB    *reloadDB
+}
+
+func TestReload(t *testing.T) {
+	t.Parallel()
+
+	cur := &reloadConfig{Port: 80, Level: "INFO", Name: "api", DB: &reloadDB{DSN: "old", MaxConns: 5}}
+	next := &reloadConfig{Port: 81, Level: "DEBUG", Name: "api", DB: &reloadDB{DSN: "new", MaxConns: 10}}
+
+	changes, err := envconf.Reload(cur, next)
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	wantChanges := []envconf.Change{
+		{Field: "Port", Env: "R_PORT", Old: "80", New: "81"},
+		{Field: "Level", Env: "R_LEVEL", Old: "INFO", New: "DEBUG", Applied: true},
+		{Field: "DB.DSN", Env: "R_DSN", Old: envconf.Redacted, New: envconf.Redacted},
+		{Field: "DB.MaxConns", Env: "R_MAX_CONNS", Old: "5", New: "10", Applied: true},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes =\n%+v\nwant\n%+v", changes, wantChanges)
+	}
+
+	wantNext := reloadConfig{Port: 80, Level: "DEBUG", Name: "api", DB: &reloadDB{DSN: "old", MaxConns: 10}}
+	if !reflect.DeepEqual(*next, wantNext) {
+		t.Errorf("next = %+v, want %+v", *next, wantNext)
+	}
+
+	wantCur := reloadConfig{Port: 80, Level: "INFO", Name: "api", DB: &reloadDB{DSN: "old", MaxConns: 5}}
+	if !reflect.DeepEqual(*cur, wantCur) {
+		t.Errorf("cur modified: %+v", *cur)
+	}
+}
+
+func TestReloadKeep(t *testing.T) {
+	t.Parallel()
+
+	cur := &reloadConfig{Level: "INFO", DB: &reloadDB{MaxConns: 5}}
+	next := &reloadConfig{Level: "DEBUG", DB: &reloadDB{MaxConns: 10}}
+
+	changes, err := envconf.Reload(cur, next, "R_MAX_CONNS")
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if len(changes) != 2 || !changes[0].Applied || changes[1].Applied {
+		t.Errorf("changes = %+v, want R_LEVEL applied and R_MAX_CONNS kept", changes)
+	}
+
+	if next.Level != "DEBUG" || next.DB.MaxConns != 5 {
+		t.Errorf("next = %+v %+v, want level reloaded and max conns kept", *next, *next.DB)
+	}
+}
+
+// Walking cur must not allocate its nil nested structs.
+func TestReloadNilNested(t *testing.T) {
+	t.Parallel()
+
+	cur := &reloadConfig{Level: "INFO"}
+	next := &reloadConfig{Level: "DEBUG", DB: &reloadDB{MaxConns: 10}}
+
+	_, err := envconf.Reload(cur, next)
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if cur.DB != nil || cur.Level != "INFO" {
+		t.Errorf("cur modified: %+v", *cur)
+	}
+
+	if next.Level != "DEBUG" || next.DB.MaxConns != 10 {
+		t.Errorf("next = %+v %+v, want level and max conns reloaded", *next, *next.DB)
+	}
+}
+
+func TestReloadMismatchedTypes(t *testing.T) {
+	t.Parallel()
+
+	_, err := envconf.Reload(new(reloadConfig), new(reloadDB))
+	if err == nil {
+		t.Fatal("Reload: want error for different struct types")
+	}
+}
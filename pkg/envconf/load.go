@@ -1,36 +1,215 @@
 package envconf
 
 import (
-	"encoding"
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
 	"strconv"
-	"time"
 )
 
+// Option configures where Load reads values from.
+type Option func(*options)
+
+type options struct {
+	flagArgs []string
+	hasFlags bool
+	files    []string
+	dotEnvs  []string
+}
+
+// field is one `env`-tagged struct field found by walk.
+type field struct {
+	v  reflect.Value
+	sf reflect.StructField
+	// name is the Go path of the field, e.g. "Postgres.DSN".
+	name string
+	// env is the variable name, `envPrefix` tags included.
+	env string
+}
+
+// loader fills fields and collects every error instead of stopping at the
+// first one.
+type loader struct {
+	sources []source
+	report  Report
+	errs    []error
+}
+
+// spec is what a field's tags ask of its value.
+type spec struct {
+	def        string
+	hasDefault bool
+	required   bool
+	format     format
+	rules      []rule
+}
+
+// FileSuffix marks a variable that holds the path of a file containing the
+// value, as orchestrators deliver secrets: PG_DSN_FILE=/run/secrets/dsn.
+const FileSuffix = "_FILE"
+
 var (
+	ErrAmbiguousSource = errors.New("variable set both directly and through a file")
 	ErrMissingRequired = errors.New("missing required environment variable")
 	ErrUnsupportedType = errors.New("unsupported field type")
+	ErrInvalidValue    = errors.New("invalid value")
+	ErrInvalidTag      = errors.New("invalid struct tag")
 )
 
-// nolint:gocognit
-func Load(dst any) error {
+// WithFlags adds command-line flags derived from the struct, parsed from
+// args: PG_DSN becomes -pg-dsn. Flags take precedence over every other
+// source. -h prints them and makes Load return flag.ErrHelp.
+func WithFlags(args []string) Option {
+	return func(o *options) {
+		o.flagArgs = args
+		o.hasFlags = true
+	}
+}
+
+// WithFile adds a YAML (.yaml, .yml) or JSON (.json) file of variable names
+// to values, below the environment. An empty path adds nothing; a missing
+// file is an error.
+func WithFile(path string) Option {
+	return func(o *options) {
+		if path != "" {
+			o.files = append(o.files, path)
+		}
+	}
+}
+
+// WithDotEnv adds a .env file of KEY=VALUE lines as the lowest-priority
+// source, above defaults. An empty path adds nothing; a file that does not
+// exist makes Load fail, so a misspelled path is not silently ignored.
+func WithDotEnv(path string) Option {
+	return func(o *options) {
+		if path != "" {
+			o.dotEnvs = append(o.dotEnvs, path)
+		}
+	}
+}
+
+// Load fills the exported fields of the struct dst points to. Fields are read
+// from the variable named by their `env` tag; untagged struct and
+// pointer-to-struct fields are loaded recursively, with the names of their
+// variables prefixed by the field's `envPrefix` tag:
+//
+//	Replica *PostgresConfig `envPrefix:"REPLICA_"` // reads REPLICA_PG_DSN, ...
+//
+// Values come from the environment and the sources added by opts, highest
+// priority first: flags, the environment, WithFile files, WithDotEnv files,
+// then `default` tags. When a variable is unset in a source but NAME_FILE is
+// set there, the value is read from that file instead (see FileSuffix).
+//
+// A tagged field may also carry:
+//   - `default:"..."`, used when no source sets the variable;
+//   - `required:"false"`, so an unset variable leaves the field as is and an
+//     empty one is neither parsed nor validated;
+//   - `validate:"..."`, comma-separated rules checked against the value:
+//     nonempty, url, oneof=a b c, min=N and max=N (numbers and durations
+//     compare values, strings, slices and maps compare lengths);
+//   - `sep:","` and `kvsep:":"`, the separators of slice elements and map
+//     entries (`KEY=a:1,b:2`);
+//   - `layout:"..."`, the time.Parse layout of a time.Time (RFC 3339 if unset).
+//
+// Besides strings, bools and numbers, fields may be time.Duration, time.Time,
+// *url.URL, ByteSize, slices and string-keyed maps of any of them, pointers,
+// or any encoding.TextUnmarshaler such as net.IP, netip.Addr, netip.Prefix
+// and slog.Level.
+//
+// Load reports every problem at once, joined into one error.
+func Load(dst any, opts ...Option) error {
+	_, err := LoadWithReport(dst, opts...)
+
+	return err
+}
+
+// LoadWithReport is Load that also reports where each field's value came
+// from, for debugging.
+//
+//nolint:cyclop
+func LoadWithReport(dst any, opts ...Option) (Report, error) {
+	v, err := structValue(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	fields := walk(v)
+
+	var l loader
+
+	if o.hasFlags {
+		fl, err := parseFlags(fields, o.flagArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		l.sources = append(l.sources, fl)
+	}
+
+	l.sources = append(l.sources, envSource{})
+
+	for _, path := range o.files {
+		s, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		l.sources = append(l.sources, s)
+	}
+
+	for _, path := range o.dotEnvs {
+		s, err := readDotEnv(path)
+		if err != nil {
+			return nil, err
+		}
+
+		l.sources = append(l.sources, s)
+	}
+
+	for _, f := range fields {
+		l.loadField(f)
+	}
+
+	return l.report, errors.Join(l.errs...)
+}
+
+func structValue(dst any) (reflect.Value, error) {
 	if dst == nil {
-		return errors.New("destination is nil")
+		return reflect.Value{}, errors.New("destination is nil")
 	}
 
 	v := reflect.ValueOf(dst)
 	if v.Kind() != reflect.Pointer || v.IsNil() {
-		return errors.New("destination must be a non-nil pointer to a struct")
+		return reflect.Value{}, errors.New("destination must be a non-nil pointer to a struct")
 	}
 
 	v = v.Elem()
 	if v.Kind() != reflect.Struct {
-		return errors.New("destination must point to a struct")
+		return reflect.Value{}, errors.New("destination must point to a struct")
 	}
 
+	return v, nil
+}
+
+// walk lists the tagged fields of v in declaration order, allocating nil
+// pointer-to-struct fields on the way.
+func walk(v reflect.Value) []field {
+	var fields []field
+
+	walkStruct(v, "", "", &fields)
+
+	return fields
+}
+
+// walkStruct appends the fields of v. Names start with namePrefix; env names
+// start with envPrefix, the concatenated `envPrefix` tags of the enclosing
+// struct fields.
+func walkStruct(v reflect.Value, namePrefix, envPrefix string, fields *[]field) {
 	t := v.Type()
 	for i := range v.NumField() {
 		sf := t.Field(i)
@@ -40,142 +219,112 @@ func Load(dst any) error {
 			continue
 		}
 
+		name := namePrefix + sf.Name
 		tag := sf.Tag.Get("env")
 
-		// No tag: recurse into embedded/nested structs (including pointer-to-struct),
-		// except time.Duration which is an int64.
+		// No tag: recurse into embedded/nested structs (including pointer-to-struct).
 		if tag == "-" || tag == "" {
-			if fv.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
-				err := Load(fv.Addr().Interface())
-				if err != nil {
-					return fmt.Errorf("load recursively %q: %w", sf.Name, err)
-				}
-
-				continue
-			}
+			nested := envPrefix + sf.Tag.Get("envPrefix")
 
-			if fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct {
+			switch {
+			case fv.Kind() == reflect.Struct:
+				walkStruct(fv, name+".", nested, fields)
+			case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct:
 				if fv.IsNil() {
 					fv.Set(reflect.New(fv.Type().Elem()))
 				}
 
-				err := Load(fv.Interface())
-				if err != nil {
-					return fmt.Errorf("load recursively %q: %w", sf.Name, err)
-				}
-
-				continue
+				walkStruct(fv.Elem(), name+".", nested, fields)
 			}
 
 			continue
 		}
 
-		raw, ok := os.LookupEnv(tag)
-		if !ok {
-			return fmt.Errorf("%w: %s (field %q)", ErrMissingRequired, tag, sf.Name)
-		}
-
-		err := setValue(fv, raw)
-		if err != nil {
-			return fmt.Errorf("parse %q for field %q: %w", tag, sf.Name, err)
-		}
+		*fields = append(*fields, field{v: fv, sf: sf, name: name, env: envPrefix + tag})
 	}
+}
 
-	return nil
+func (l *loader) fail(err error) {
+	l.errs = append(l.errs, err)
 }
 
-//nolint:gocognit,cyclop
-func setValue(fv reflect.Value, raw string) error {
-	if !fv.CanSet() {
-		return fmt.Errorf("field not settable: %w", ErrUnsupportedType)
+func (l *loader) loadField(f field) {
+	spec, err := parseSpec(f.sf)
+	if err != nil {
+		l.fail(fmt.Errorf("%w: field %q: %w", ErrInvalidTag, f.name, err))
+
+		return
 	}
 
-	// encoding.TextUnmarshaler support
-	if fv.CanAddr() {
-		u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler)
-		if ok {
-			err := u.UnmarshalText([]byte(raw))
-			if err != nil {
-				return fmt.Errorf("unmarshal text: %w", err)
-			}
+	raw, from, err := l.lookup(f.env)
+	if err != nil {
+		l.fail(fmt.Errorf("read %s for field %q: %w", f.env, f.name, err))
 
-			return nil
-		}
+		return
 	}
 
-	switch fv.Kind() {
-	case reflect.String:
-		fv.SetString(raw)
-
-		return nil
-	case reflect.Bool:
-		b, err := strconv.ParseBool(raw)
-		if err != nil {
-			return fmt.Errorf("parse bool: %w", err)
-		}
+	switch {
+	case from != "":
+	case spec.hasDefault:
+		raw, from = spec.def, SourceDefault
+	case !spec.required:
+		l.report = append(l.report, Origin{Field: f.name, Env: f.env, Source: SourceUnset})
 
-		fv.SetBool(b)
+		return
+	default:
+		l.fail(fmt.Errorf("%w: %s (field %q)", ErrMissingRequired, f.env, f.name))
 
-		return nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
-			d, err := time.ParseDuration(raw)
-			if err != nil {
-				return fmt.Errorf("parse duration: %w", err)
-			}
+		return
+	}
 
-			fv.SetInt(int64(d))
+	l.report = append(l.report, Origin{Field: f.name, Env: f.env, Source: from})
 
-			return nil
-		}
+	if raw == "" && !spec.required {
+		return
+	}
 
-		i, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
-		if err != nil {
-			return fmt.Errorf("parse int: %w", err)
-		}
+	err = setValue(f.v, raw, spec.format)
+	if err != nil {
+		l.fail(fmt.Errorf("parse %q for field %q: %w", f.env, f.name, err))
 
-		fv.SetInt(i)
+		return
+	}
 
-		return nil
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		u, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+	for _, r := range spec.rules {
+		err := r.check(f.v, raw)
 		if err != nil {
-			return fmt.Errorf("parse uint: %w", err)
+			l.fail(fmt.Errorf("%w: %s (field %q) %w", ErrInvalidValue, f.env, f.name, err))
 		}
+	}
+}
 
-		fv.SetUint(u)
+func parseSpec(sf reflect.StructField) (spec, error) {
+	s := spec{required: true}
 
-		return nil
-	case reflect.Float32, reflect.Float64:
-		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+	s.def, s.hasDefault = sf.Tag.Lookup("default")
+
+	if req, ok := sf.Tag.Lookup("required"); ok {
+		b, err := strconv.ParseBool(req)
 		if err != nil {
-			return fmt.Errorf("parse float: %w", err)
+			return spec{}, fmt.Errorf("required: %w", err)
 		}
 
-		fv.SetFloat(f)
-
-		return nil
-	case reflect.Pointer:
-		if fv.IsNil() {
-			elem := reflect.New(fv.Type().Elem())
+		s.required = b
+	}
 
-			err := setValue(elem.Elem(), raw)
-			if err != nil {
-				return fmt.Errorf("parse pointer: %w", err)
-			}
+	f, err := parseFormat(sf)
+	if err != nil {
+		return spec{}, err
+	}
 
-			fv.Set(elem)
+	s.format = f
 
-			return nil
-		}
+	rules, err := parseRules(sf.Tag.Get("validate"))
+	if err != nil {
+		return spec{}, err
+	}
 
-		err := setValue(fv.Elem(), raw)
-		if err != nil {
-			return fmt.Errorf("parse pointer: %w", err)
-		}
+	s.rules = rules
 
-		return nil
-	default:
-		return fmt.Errorf("unsupported type: %w", ErrUnsupportedType)
-	}
+	return s, nil
 }
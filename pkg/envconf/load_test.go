@@ -1,3 +1,321 @@
 package envconf_test
 
-// TODO: add tests
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+type nested struct {
+	Timeout time.Duration `env:"T_TIMEOUT" default:"5s" validate:"min=1s,max=1m"`
+}
+
+type testConfig struct {
+	Name     string   `env:"T_NAME" validate:"nonempty"`
+	Port     uint16   `env:"T_PORT" default:"8080" validate:"min=1"`
+	Mode     string   `env:"T_MODE" default:"fast" validate:"oneof=fast slow"`
+	Endpoint string   `env:"T_ENDPOINT" required:"false" validate:"url"`
+	Ratio    *float64 `env:"T_RATIO" required:"false" validate:"min=0,max=1"`
+	Nested   *nested
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *testConfig)
+		wantErr []error
+		// wantMsg are substrings the joined error must contain.
+		wantMsg []string
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"T_NAME": "api"},
+			check: func(t *testing.T, cfg *testConfig) {
+				t.Helper()
+
+				if cfg.Port != 8080 || cfg.Mode != "fast" || cfg.Nested.Timeout != 5*time.Second {
+					t.Errorf("defaults not applied: %+v %+v", cfg, cfg.Nested)
+				}
+
+				if cfg.Endpoint != "" || cfg.Ratio != nil {
+					t.Errorf("optional fields set: %q %v", cfg.Endpoint, cfg.Ratio)
+				}
+			},
+		},
+		{
+			name: "environment overrides defaults",
+			env: map[string]string{
+				"T_NAME": "api", "T_PORT": "9090", "T_MODE": "slow",
+				"T_ENDPOINT": "http://collector:4318", "T_RATIO": "0.5", "T_TIMEOUT": "30s",
+			},
+			check: func(t *testing.T, cfg *testConfig) {
+				t.Helper()
+
+				if cfg.Port != 9090 || cfg.Mode != "slow" || cfg.Endpoint != "http://collector:4318" ||
+					cfg.Ratio == nil || *cfg.Ratio != 0.5 || cfg.Nested.Timeout != 30*time.Second {
+					t.Errorf("unexpected config: %+v %+v", cfg, cfg.Nested)
+				}
+			},
+		},
+		{
+			name: "empty optional value is not validated",
+			env:  map[string]string{"T_NAME": "api", "T_ENDPOINT": ""},
+		},
+		{
+			name:    "missing required",
+			env:     map[string]string{},
+			wantErr: []error{envconf.ErrMissingRequired},
+			wantMsg: []string{"T_NAME"},
+		},
+		{
+			name: "every problem is reported",
+			env: map[string]string{
+				"T_NAME": " ", "T_PORT": "0", "T_MODE": "medium",
+				"T_ENDPOINT": "collector", "T_RATIO": "2", "T_TIMEOUT": "1ms",
+			},
+			wantErr: []error{envconf.ErrInvalidValue},
+			wantMsg: []string{
+				"T_NAME (field \"Name\") must not be empty",
+				"T_PORT (field \"Port\") must be at least 1",
+				"T_MODE (field \"Mode\") must be one of fast, slow",
+				"T_ENDPOINT (field \"Endpoint\") must be an absolute URL",
+				"T_RATIO (field \"Ratio\") must be at most 1",
+				"T_TIMEOUT (field \"Nested.Timeout\") must be at least 1s",
+			},
+		},
+		{
+			name:    "parse errors and missing values together",
+			env:     map[string]string{"T_PORT": "http"},
+			wantErr: []error{envconf.ErrMissingRequired},
+			wantMsg: []string{"T_NAME", `parse "T_PORT" for field "Port"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"T_NAME", "T_PORT", "T_MODE", "T_ENDPOINT", "T_RATIO", "T_TIMEOUT"} {
+				t.Setenv(k, "")
+				unsetenv(t, k)
+			}
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg := new(testConfig)
+			err := envconf.Load(cfg)
+
+			if len(tt.wantErr) == 0 && len(tt.wantMsg) == 0 {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+
+				if tt.check != nil {
+					tt.check(t, cfg)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("error %v is not %v", err, want)
+				}
+			}
+
+			for _, want := range tt.wantMsg {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error does not mention %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadInvalidTags(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  any
+	}{
+		{name: "unknown rule", dst: &struct {
+			A string `env:"T_TAG" validate:"email"`
+		}{}},
+		{name: "rule without argument", dst: &struct {
+			A string `env:"T_TAG" validate:"oneof="`
+		}{}},
+		{name: "bad required", dst: &struct {
+			A string `env:"T_TAG" required:"maybe"`
+		}{}},
+		{name: "bound of the wrong type", dst: &struct {
+			A int `env:"T_TAG" validate:"min=1s"`
+		}{}},
+		{name: "bound on unsupported type", dst: &struct {
+			A bool `env:"T_TAG" validate:"max=1"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("T_TAG", "1")
+
+			err := envconf.Load(tt.dst)
+			if !errors.Is(err, envconf.ErrInvalidTag) {
+				t.Fatalf("err = %v, want ErrInvalidTag", err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsNonStruct(t *testing.T) {
+	t.Parallel()
+
+	for _, dst := range []any{nil, testConfig{}, new(int), (*testConfig)(nil)} {
+		if err := envconf.Load(dst); err == nil {
+			t.Errorf("Load(%T) succeeded", dst)
+		}
+	}
+}
+
+// unsetenv removes k for the rest of the test; t.Setenv has registered the
+// restore.
+func unsetenv(t *testing.T, k string) {
+	t.Helper()
+
+	err := os.Unsetenv(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type dbConfig struct {
+	DSN      string `env:"DB_DSN"`
+	MaxConns int    `env:"DB_MAX_CONNS" default:"10"`
+}
+
+func TestLoadEnvPrefix(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://primary")
+	t.Setenv("REPLICA_DB_DSN", "postgres://replica")
+	t.Setenv("REPLICA_DB_MAX_CONNS", "4")
+	t.Setenv("ANALYTICS_REPLICA_DB_DSN", "postgres://analytics")
+
+	cfg := &struct {
+		Primary   dbConfig
+		Replica   *dbConfig `envPrefix:"REPLICA_"`
+		Analytics struct {
+			Replica dbConfig `envPrefix:"REPLICA_"`
+		} `envPrefix:"ANALYTICS_"`
+	}{}
+
+	err := envconf.Load(cfg)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := []dbConfig{
+		{DSN: "postgres://primary", MaxConns: 10},
+		{DSN: "postgres://replica", MaxConns: 4},
+		{DSN: "postgres://analytics", MaxConns: 10},
+	}
+
+	for i, got := range []dbConfig{cfg.Primary, *cfg.Replica, cfg.Analytics.Replica} {
+		if got != want[i] {
+			t.Errorf("config %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	unsetenv(t, "REPLICA_DB_DSN")
+
+	err = envconf.Load(cfg)
+	if !errors.Is(err, envconf.ErrMissingRequired) || !strings.Contains(err.Error(), "REPLICA_DB_DSN") {
+		t.Fatalf("err = %v, want missing REPLICA_DB_DSN", err)
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	secret := filepath.Join(dir, "dsn")
+
+	err := os.WriteFile(secret, []byte("postgres://user:s3cret@db/app\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "file",
+			env:  map[string]string{"REPLICA_DB_DSN_FILE": secret},
+			want: "postgres://user:s3cret@db/app",
+		},
+		{
+			name: "plain variable",
+			env:  map[string]string{"REPLICA_DB_DSN": "postgres://plain"},
+			want: "postgres://plain",
+		},
+		{
+			name:    "both set",
+			env:     map[string]string{"REPLICA_DB_DSN": "postgres://plain", "REPLICA_DB_DSN_FILE": secret},
+			wantErr: envconf.ErrAmbiguousSource,
+		},
+		{
+			name: "empty file variable",
+			env:  map[string]string{"REPLICA_DB_DSN": "postgres://plain", "REPLICA_DB_DSN_FILE": ""},
+			want: "postgres://plain",
+		},
+		{
+			name:    "empty file variable alone",
+			env:     map[string]string{"REPLICA_DB_DSN_FILE": ""},
+			wantErr: envconf.ErrMissingRequired,
+		},
+		{
+			name:    "missing file",
+			env:     map[string]string{"REPLICA_DB_DSN_FILE": filepath.Join(dir, "nope")},
+			wantErr: fs.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg := &struct {
+				Replica dbConfig `envPrefix:"REPLICA_"`
+			}{}
+
+			err := envconf.Load(cfg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Replica.DSN != tt.want {
+				t.Errorf("DSN = %q, want %q", cfg.Replica.DSN, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package envconf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// format is how a raw value is split and parsed, from the field's tags.
+type format struct {
+	sep    string
+	kvSep  string
+	layout string
+}
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	timeType     = reflect.TypeFor[time.Time]()
+	urlType      = reflect.TypeFor[url.URL]()
+)
+
+func parseFormat(sf reflect.StructField) (format, error) {
+	f := format{sep: ",", kvSep: ":", layout: time.RFC3339}
+
+	if sep, ok := sf.Tag.Lookup("sep"); ok {
+		f.sep = sep
+	}
+
+	if kvSep, ok := sf.Tag.Lookup("kvsep"); ok {
+		f.kvSep = kvSep
+	}
+
+	if layout, ok := sf.Tag.Lookup("layout"); ok {
+		f.layout = layout
+	}
+
+	if f.sep == "" || f.kvSep == "" || f.sep == f.kvSep {
+		return format{}, errors.New("sep and kvsep must be non-empty and different")
+	}
+
+	return f, nil
+}
+
+//nolint:gocognit,cyclop
+func setValue(fv reflect.Value, raw string, f format) error {
+	if !fv.CanSet() {
+		return fmt.Errorf("field not settable: %w", ErrUnsupportedType)
+	}
+
+	// Types with their own syntax go before TextUnmarshaler: time.Time
+	// implements it, but only for RFC 3339.
+	switch fv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("parse duration: %w", err)
+		}
+
+		fv.SetInt(int64(d))
+
+		return nil
+	case timeType:
+		t, err := time.Parse(f.layout, raw)
+		if err != nil {
+			return fmt.Errorf("parse time: %w", err)
+		}
+
+		fv.Set(reflect.ValueOf(t))
+
+		return nil
+	case urlType:
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("parse url: %w", err)
+		}
+
+		fv.Set(reflect.ValueOf(*u))
+
+		return nil
+	}
+
+	// encoding.TextUnmarshaler support
+	if fv.CanAddr() {
+		u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler)
+		if ok {
+			err := u.UnmarshalText([]byte(raw))
+			if err != nil {
+				return fmt.Errorf("unmarshal text: %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("parse bool: %w", err)
+		}
+
+		fv.SetBool(b)
+
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse int: %w", err)
+		}
+
+		fv.SetInt(i)
+
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse uint: %w", err)
+		}
+
+		fv.SetUint(u)
+
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse float: %w", err)
+		}
+
+		fv.SetFloat(f)
+
+		return nil
+	case reflect.Slice:
+		return setSlice(fv, raw, f)
+	case reflect.Map:
+		return setMap(fv, raw, f)
+	case reflect.Pointer:
+		if fv.IsNil() {
+			elem := reflect.New(fv.Type().Elem())
+
+			err := setValue(elem.Elem(), raw, f)
+			if err != nil {
+				return fmt.Errorf("parse pointer: %w", err)
+			}
+
+			fv.Set(elem)
+
+			return nil
+		}
+
+		err := setValue(fv.Elem(), raw, f)
+		if err != nil {
+			return fmt.Errorf("parse pointer: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %w", ErrUnsupportedType)
+	}
+}
+
+// splitList splits raw on sep, trimming spaces. An empty raw is an empty list.
+func splitList(raw, sep string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	parts := strings.Split(raw, sep)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts
+}
+
+// setSlice replaces fv with the elements of raw, each parsed like a field of
+// the element type.
+func setSlice(fv reflect.Value, raw string, f format) error {
+	parts := splitList(raw, f.sep)
+	s := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+
+	for i, p := range parts {
+		err := setValue(s.Index(i), p, f)
+		if err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	fv.Set(s)
+
+	return nil
+}
+
+// setMap replaces fv with the key/value entries of raw.
+func setMap(fv reflect.Value, raw string, f format) error {
+	t := fv.Type()
+	if t.Key().Kind() != reflect.String {
+		return fmt.Errorf("map key %s: %w", t.Key(), ErrUnsupportedType)
+	}
+
+	parts := splitList(raw, f.sep)
+	m := reflect.MakeMapWithSize(t, len(parts))
+
+	for _, p := range parts {
+		k, v, ok := strings.Cut(p, f.kvSep)
+		if !ok {
+			return fmt.Errorf("entry %q: missing %q", p, f.kvSep)
+		}
+
+		key := reflect.New(t.Key()).Elem()
+		key.SetString(strings.TrimSpace(k))
+
+		if m.MapIndex(key).IsValid() {
+			return fmt.Errorf("duplicate key %q", key.String())
+		}
+
+		val := reflect.New(t.Elem()).Elem()
+
+		err := setValue(val, strings.TrimSpace(v), f)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", key.String(), err)
+		}
+
+		m.SetMapIndex(key, val)
+	}
+
+	fv.Set(m)
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package envconf_test
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+func TestLoadTypes(t *testing.T) {
+	mustURL := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	tests := []struct {
+		name string
+		// dst is a pointer to a struct whose single field V is tagged
+		// `env:"T_VALUE"`.
+		dst     any
+		raw     string
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "string slice",
+			dst: &struct {
+				V []string `env:"T_VALUE"`
+			}{},
+			raw:  "a.example.com, b.example.com ,c.example.com",
+			want: []string{"a.example.com", "b.example.com", "c.example.com"},
+		},
+		{
+			name: "empty slice",
+			dst: &struct {
+				V []string `env:"T_VALUE"`
+			}{},
+			raw:  "",
+			want: []string{},
+		},
+		{
+			name: "int slice with separator",
+			dst: &struct {
+				V []int `env:"T_VALUE" sep:";"`
+			}{},
+			raw:  "1;2;3",
+			want: []int{1, 2, 3},
+		},
+		{
+			name: "bad slice element",
+			dst: &struct {
+				V []int `env:"T_VALUE"`
+			}{},
+			raw:     "1,x",
+			wantErr: true,
+		},
+		{
+			name: "duration slice",
+			dst: &struct {
+				V []time.Duration `env:"T_VALUE"`
+			}{},
+			raw:  "1s,500ms",
+			want: []time.Duration{time.Second, 500 * time.Millisecond},
+		},
+		{
+			name: "map",
+			dst: &struct {
+				V map[string]int `env:"T_VALUE"`
+			}{},
+			raw:  "game:10, server:20",
+			want: map[string]int{"game": 10, "server": 20},
+		},
+		{
+			name: "map with separators",
+			dst: &struct {
+				V map[string]string `env:"T_VALUE" sep:";" kvsep:"="`
+			}{},
+			raw:  "primary=postgres://a:5432/db;replica=postgres://b:5432/db",
+			want: map[string]string{"primary": "postgres://a:5432/db", "replica": "postgres://b:5432/db"},
+		},
+		{
+			name: "map entry without separator",
+			dst: &struct {
+				V map[string]int `env:"T_VALUE"`
+			}{},
+			raw:     "game",
+			wantErr: true,
+		},
+		{
+			name: "map duplicate key",
+			dst: &struct {
+				V map[string]int `env:"T_VALUE"`
+			}{},
+			raw:     "a:1,a:2",
+			wantErr: true,
+		},
+		{
+			name: "map with non-string keys",
+			dst: &struct {
+				V map[int]int `env:"T_VALUE"`
+			}{},
+			raw:     "1:1",
+			wantErr: true,
+		},
+		{
+			name: "url",
+			dst: &struct {
+				V *url.URL `env:"T_VALUE"`
+			}{},
+			raw:  "https://collector:4318/v1/traces?x=1",
+			want: mustURL("https://collector:4318/v1/traces?x=1"),
+		},
+		{
+			name: "bad url",
+			dst: &struct {
+				V *url.URL `env:"T_VALUE"`
+			}{},
+			raw:     "http://[::1",
+			wantErr: true,
+		},
+		{
+			name: "ip",
+			dst: &struct {
+				V net.IP `env:"T_VALUE"`
+			}{},
+			raw:  "10.0.0.1",
+			want: net.ParseIP("10.0.0.1"),
+		},
+		{
+			name: "bad ip",
+			dst: &struct {
+				V net.IP `env:"T_VALUE"`
+			}{},
+			raw:     "10.0.0.256",
+			wantErr: true,
+		},
+		{
+			name: "prefix allowlist",
+			dst: &struct {
+				V []netip.Prefix `env:"T_VALUE"`
+			}{},
+			raw:  "10.0.0.0/8,2001:db8::/32",
+			want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")},
+		},
+		{
+			name: "bad prefix",
+			dst: &struct {
+				V netip.Prefix `env:"T_VALUE"`
+			}{},
+			raw:     "10.0.0.0/33",
+			wantErr: true,
+		},
+		{
+			name: "time RFC 3339",
+			dst: &struct {
+				V time.Time `env:"T_VALUE"`
+			}{},
+			raw:  "2025-03-01T12:30:00Z",
+			want: time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "time with layout",
+			dst: &struct {
+				V time.Time `env:"T_VALUE" layout:"2006-01-02"`
+			}{},
+			raw:  "2025-03-01",
+			want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "time not matching layout",
+			dst: &struct {
+				V time.Time `env:"T_VALUE" layout:"2006-01-02"`
+			}{},
+			raw:     "2025-03-01T12:30:00Z",
+			wantErr: true,
+		},
+		{
+			name: "byte size",
+			dst: &struct {
+				V envconf.ByteSize `env:"T_VALUE"`
+			}{},
+			raw:  "10MB",
+			want: envconf.ByteSize(10_000_000),
+		},
+		{
+			name: "byte sizes",
+			dst: &struct {
+				V []envconf.ByteSize `env:"T_VALUE"`
+			}{},
+			raw:  "512,1KiB,1.5GiB",
+			want: []envconf.ByteSize{512, 1 << 10, 3 << 29},
+		},
+		{
+			name: "unsupported type",
+			dst: &struct {
+				V chan int `env:"T_VALUE"`
+			}{},
+			raw:     "1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("T_VALUE", tt.raw)
+
+			err := envconf.Load(tt.dst)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Load succeeded with %v, want an error", reflect.ValueOf(tt.dst).Elem().Field(0))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			got := reflect.ValueOf(tt.dst).Elem().Field(0).Interface()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLengthRules(t *testing.T) {
+	dst := &struct {
+		Hosts []string       `env:"T_HOSTS" validate:"min=2"`
+		Mix   map[string]int `env:"T_MIX" validate:"max=1"`
+	}{}
+
+	t.Setenv("T_HOSTS", "a")
+	t.Setenv("T_MIX", "a:1,b:2")
+
+	err := envconf.Load(dst)
+	if !errors.Is(err, envconf.ErrInvalidValue) {
+		t.Fatalf("err = %v, want ErrInvalidValue", err)
+	}
+
+	for _, want := range []string{"must have at least 2 entries", "must have at most 1 entries"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    envconf.ByteSize
+		wantStr string
+		wantErr bool
+	}{
+		{in: "0", want: 0, wantStr: "0B"},
+		{in: "512", want: 512, wantStr: "512B"},
+		{in: "512B", want: 512, wantStr: "512B"},
+		{in: "10MB", want: 10_000_000, wantStr: "10000000B"},
+		{in: "1.1kb", want: 1100, wantStr: "1100B"},
+		{in: "2KiB", want: 2048, wantStr: "2KiB"},
+		{in: "1.5 GiB", want: 3 << 29, wantStr: "1536MiB"},
+		{in: "16TiB", want: 16 << 40, wantStr: "16TiB"},
+		{in: "0.5B", wantErr: true},
+		{in: "10XB", wantErr: true},
+		{in: "MB", wantErr: true},
+		{in: "-1MB", wantErr: true},
+		{in: "1.2.3KB", wantErr: true},
+		{in: "20000000TB", wantErr: true},
+		{in: "0." + strings.Repeat("0", 63) + "1", wantErr: true},
+		{in: "1." + strings.Repeat("0", 20) + "KB", wantErr: true},
+		{in: "1." + strings.Repeat("0", 15) + "KB", want: 1000, wantStr: "1000B"},
+	}
+
+	for _, tt := range tests {
+		var got envconf.ByteSize
+
+		err := got.UnmarshalText([]byte(tt.in))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: got %d, want an error", tt.in, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+
+			continue
+		}
+
+		if got != tt.want || got.String() != tt.wantStr {
+			t.Errorf("%q: got %d (%s), want %d (%s)", tt.in, got, got, tt.want, tt.wantStr)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package envconf
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Origin is where one field's value came from.
+type Origin struct {
+	Field  string
+	Env    string
+	Source string
+}
+
+// Report lists the Origin of every field, in declaration order.
+type Report []Origin
+
+// String formats r as one "ENV=source" pair per line.
+func (r Report) String() string {
+	var b strings.Builder
+
+	for _, o := range r {
+		b.WriteString(o.Env)
+		b.WriteByte('=')
+		b.WriteString(o.Source)
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer as a group of ENV: source attributes.
+func (r Report) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(r))
+	for _, o := range r {
+		attrs = append(attrs, slog.String(o.Env, o.Source))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,298 @@
+package envconf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source is one layer of values, looked up by variable name.
+type source interface {
+	name() string
+	lookup(key string) (string, bool)
+}
+
+type envSource struct{}
+
+// mapSource holds values read up front from flags or a file.
+type mapSource struct {
+	label  string
+	values map[string]string
+}
+
+// Names of the sources an Origin reports. File sources are reported as
+// "file <path>" and ".env <path>".
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceDefault = "default"
+	// SourceUnset marks an optional field that no source set.
+	SourceUnset = "unset"
+)
+
+var errDotEnvSyntax = errors.New("invalid .env line")
+
+func (envSource) name() string { return SourceEnv }
+
+func (envSource) lookup(key string) (string, bool) { return os.LookupEnv(key) }
+
+func (s mapSource) name() string { return s.label }
+
+func (s mapSource) lookup(key string) (string, bool) {
+	v, ok := s.values[key]
+
+	return v, ok
+}
+
+// lookup returns the value of env from the highest-priority source that has
+// it, or the contents of the file named by env+FileSuffix there, without its
+// trailing newline. An empty env+FileSuffix counts as unset. from is the
+// source name, or "" if no source has it.
+func (l *loader) lookup(env string) (raw, from string, err error) {
+	for _, s := range l.sources {
+		raw, ok := s.lookup(env)
+		path, _ := s.lookup(env + FileSuffix)
+		fromFile := path != ""
+
+		switch {
+		case ok && fromFile:
+			return "", "", fmt.Errorf("%w: %s and %s%s in %s", ErrAmbiguousSource, env, env, FileSuffix, s.name())
+		case ok:
+			return raw, s.name(), nil
+		case fromFile:
+			b, err := os.ReadFile(path) //nolint:gosec
+			if err != nil {
+				return "", "", fmt.Errorf("read %s%s: %w", env, FileSuffix, err)
+			}
+
+			return strings.TrimRight(string(b), "\r\n"), s.name() + " (" + env + FileSuffix + ")", nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// flagName turns PG_DSN into pg-dsn.
+func flagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+func parseFlags(fields []field, args []string) (source, error) {
+	src := mapSource{label: SourceFlag, values: map[string]string{}}
+	set := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+
+	for _, f := range fields {
+		name := flagName(f.env)
+		if set.Lookup(name) != nil {
+			continue
+		}
+
+		env := f.env
+		store := func(s string) error {
+			src.values[env] = s
+
+			return nil
+		}
+
+		usage := "env " + env
+		if def, ok := f.sf.Tag.Lookup("default"); ok {
+			usage += ", default " + strconv.Quote(def)
+		}
+
+		if f.v.Kind() == reflect.Bool {
+			set.BoolFunc(name, usage, store)
+		} else {
+			set.Func(name, usage, store)
+		}
+	}
+
+	err := set.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("parse flags: %w", err)
+	}
+
+	if set.NArg() > 0 {
+		return nil, fmt.Errorf("parse flags: unexpected arguments %q", set.Args())
+	}
+
+	return src, nil
+}
+
+// readFile reads a flat YAML or JSON object of variable names to scalar
+// values. Lists become comma-separated values.
+func readFile(path string) (source, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]any
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unknown format, want .yaml, .yml or .json", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	src := mapSource{label: "file " + path, values: make(map[string]string, len(doc))}
+
+	for k, v := range doc {
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", path, k, err)
+		}
+
+		src.values[k] = s
+	}
+
+	return src, nil
+}
+
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, json.Number:
+		return fmt.Sprint(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, len(v))
+
+		for i, e := range v {
+			s, err := scalarString(e)
+			if err != nil {
+				return "", err
+			}
+
+			parts[i] = s
+		}
+
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T; use flat NAME: value pairs", v)
+	}
+}
+
+// readDotEnv reads a .env file. The file was named explicitly, so a missing
+// one is an error rather than an empty source.
+func readDotEnv(path string) (source, error) {
+	src := mapSource{label: ".env " + path, values: map[string]string{}}
+
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("read .env file: %w", err)
+	}
+
+	src.values, err = parseDotEnv(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return src, nil
+}
+
+// parseDotEnv parses KEY=VALUE lines. Blank lines and lines starting with #
+// are skipped, and an `export ` prefix is allowed. Values may be
+// double-quoted (Go escapes apply), single-quoted (literal) or bare, where a
+// " #" starts a comment. Variables are not expanded.
+func parseDotEnv(b []byte) (map[string]string, error) {
+	values := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: %w: want KEY=VALUE", n, errDotEnvSyntax)
+		}
+
+		val, err := dotEnvValue(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		values[key] = val
+	}
+
+	err := sc.Err()
+	if err != nil {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	return values, nil
+}
+
+func dotEnvValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		end := closingQuote(v)
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated double quote", errDotEnvSyntax)
+		}
+
+		s, err := strconv.Unquote(v[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", errDotEnvSyntax, err)
+		}
+
+		return s, nil
+	case strings.HasPrefix(v, "'"):
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated single quote", errDotEnvSyntax)
+		}
+
+		return v[1 : end+1], nil
+	default:
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+
+		return v, nil
+	}
+}
+
+// closingQuote returns the index of the unescaped " closing the string v
+// opens, or -1.
+func closingQuote(v string) int {
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
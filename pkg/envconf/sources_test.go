@@ -0,0 +1,208 @@
+package envconf_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fastprodman/EntainHW/pkg/envconf"
+)
+
+type layeredConfig struct {
+	Name   string   `env:"L_NAME"`
+	Mode   string   `env:"L_MODE"`
+	Level  string   `env:"L_LEVEL"`
+	Port   int      `env:"L_PORT"`
+	Debug  bool     `env:"L_DEBUG" default:"false"`
+	Hosts  []string `env:"L_HOSTS" required:"false"`
+	Secret string   `env:"L_SECRET" required:"false"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadLayeredSources(t *testing.T) {
+	dotEnv := writeFile(t, ".env", "L_NAME=dotenv\nL_MODE=dotenv\nL_LEVEL=dotenv\nL_PORT=1\n")
+	yamlFile := writeFile(t, "config.yaml", "L_MODE: file\nL_LEVEL: file\nL_PORT: 2\nL_HOSTS: [a, b]\n")
+
+	t.Setenv("L_LEVEL", "env")
+	t.Setenv("L_PORT", "3")
+
+	cfg := new(layeredConfig)
+
+	report, err := envconf.LoadWithReport(cfg,
+		envconf.WithFlags([]string{"-l-port", "4", "-l-debug"}),
+		envconf.WithFile(yamlFile),
+		envconf.WithDotEnv(dotEnv),
+	)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := layeredConfig{Name: "dotenv", Mode: "file", Level: "env", Port: 4, Debug: true, Hosts: []string{"a", "b"}}
+	if !reflect.DeepEqual(*cfg, want) {
+		t.Errorf("config = %+v, want %+v", *cfg, want)
+	}
+
+	wantSources := map[string]string{
+		"L_NAME":   ".env " + dotEnv,
+		"L_MODE":   "file " + yamlFile,
+		"L_LEVEL":  envconf.SourceEnv,
+		"L_PORT":   envconf.SourceFlag,
+		"L_DEBUG":  envconf.SourceFlag,
+		"L_HOSTS":  "file " + yamlFile,
+		"L_SECRET": envconf.SourceUnset,
+	}
+
+	if len(report) != len(wantSources) {
+		t.Fatalf("report has %d entries, want %d:\n%s", len(report), len(wantSources), report)
+	}
+
+	for _, o := range report {
+		if o.Source != wantSources[o.Env] {
+			t.Errorf("%s (%s) from %q, want %q", o.Env, o.Field, o.Source, wantSources[o.Env])
+		}
+	}
+}
+
+func TestLoadSecretFileFromDotEnv(t *testing.T) {
+	secret := writeFile(t, "secret", "s3cret\n")
+	dotEnv := writeFile(t, ".env", "L_NAME=a\nL_MODE=b\nL_LEVEL=c\nL_PORT=1\nL_SECRET_FILE="+secret+"\n")
+
+	cfg := new(layeredConfig)
+
+	report, err := envconf.LoadWithReport(cfg, envconf.WithDotEnv(dotEnv))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Secret != "s3cret" {
+		t.Errorf("Secret = %q, want s3cret", cfg.Secret)
+	}
+
+	if got := report[len(report)-1].Source; got != ".env "+dotEnv+" (L_SECRET_FILE)" {
+		t.Errorf("source = %q", got)
+	}
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	path := writeFile(t, "config.json",
+		`{"L_NAME": "json", "L_MODE": "m", "L_LEVEL": "l", "L_PORT": 8080, "L_DEBUG": true, "L_HOSTS": ["x", "y"]}`)
+
+	cfg := new(layeredConfig)
+
+	err := envconf.Load(cfg, envconf.WithFile(path))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := layeredConfig{Name: "json", Mode: "m", Level: "l", Port: 8080, Debug: true, Hosts: []string{"x", "y"}}
+	if !reflect.DeepEqual(*cfg, want) {
+		t.Errorf("config = %+v, want %+v", *cfg, want)
+	}
+}
+
+func TestDotEnvSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "bare", content: "L_NAME=plain value", want: "plain value"},
+		{name: "comment", content: "# note\n\nL_NAME=v # trailing", want: "v"},
+		{name: "export", content: "export L_NAME=v", want: "v"},
+		{name: "double quoted", content: `L_NAME="a # b\tc \"q\""`, want: "a # b\tc \"q\""},
+		{name: "single quoted", content: `L_NAME='a\tb # c'`, want: `a\tb # c`},
+		{name: "empty", content: "L_NAME=", want: ""},
+		{name: "equals in value", content: "L_NAME=postgres://u:p@h/db?sslmode=disable", want: "postgres://u:p@h/db?sslmode=disable"},
+		{name: "no equals", content: "L_NAME", wantErr: true},
+		{name: "unterminated", content: `L_NAME="abc`, wantErr: true},
+		{name: "space in key", content: "L NAME=v", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, ".env", tt.content+"\nL_MODE=m\nL_LEVEL=l\nL_PORT=1\n")
+
+			cfg := new(layeredConfig)
+
+			err := envconf.Load(cfg, envconf.WithDotEnv(path))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Load succeeded with Name %q, want an error", cfg.Name)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Name != tt.want {
+				t.Errorf("Name = %q, want %q", cfg.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		opts    []envconf.Option
+		wantErr error
+	}{
+		{
+			name:    "missing dotenv",
+			opts:    []envconf.Option{envconf.WithDotEnv(filepath.Join(dir, "nope"))},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:    "missing config file",
+			opts:    []envconf.Option{envconf.WithFile(filepath.Join(dir, "nope.yaml"))},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:    "help",
+			opts:    []envconf.Option{envconf.WithFlags([]string{"-h"})},
+			wantErr: flag.ErrHelp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := envconf.Load(new(layeredConfig), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for name, opt := range map[string]envconf.Option{
+		"unknown flag":       envconf.WithFlags([]string{"-nope"}),
+		"positional args":    envconf.WithFlags([]string{"extra"}),
+		"unknown format":     envconf.WithFile(writeFile(t, "config.toml", "")),
+		"nested config file": envconf.WithFile(writeFile(t, "nested.yaml", "L_NAME:\n  a: b\n")),
+	} {
+		err := envconf.Load(new(layeredConfig), opt)
+		if err == nil {
+			t.Errorf("%s: Load succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package envconf
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// rule is one `validate` tag entry, checked against the parsed field and the
+// raw value it was parsed from.
+type rule struct {
+	name string
+	arg  string
+}
+
+var errUnknownRule = errors.New("unknown validate rule")
+
+func parseRules(tag string) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	var rules []rule
+
+	for part := range strings.SplitSeq(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch name {
+		case "nonempty", "url":
+		case "min", "max", "oneof":
+			if arg == "" {
+				return nil, fmt.Errorf("validate: %s needs an argument", name)
+			}
+		default:
+			return nil, fmt.Errorf("validate: %w %q", errUnknownRule, name)
+		}
+
+		rules = append(rules, rule{name: name, arg: arg})
+	}
+
+	return rules, nil
+}
+
+func (r rule) check(fv reflect.Value, raw string) error {
+	switch r.name {
+	case "nonempty":
+		if strings.TrimSpace(raw) == "" {
+			return errors.New("must not be empty")
+		}
+	case "url":
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be an absolute URL")
+		}
+	case "oneof":
+		allowed := strings.Fields(r.arg)
+		if !slices.Contains(allowed, raw) {
+			return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+		}
+	case "min", "max":
+		return r.checkBound(fv)
+	}
+
+	return nil
+}
+
+// checkBound compares numbers and durations by value, and strings, slices
+// and maps by length.
+// The bound is parsed like a value of the field's own type.
+func (r rule) checkBound(fv reflect.Value) error {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return r.checkLen(utf8.RuneCountInString(fv.String()), "must be %s %d characters long")
+	case reflect.Slice, reflect.Map:
+		return r.checkLen(fv.Len(), "must have %s %d entries")
+	}
+
+	bound := reflect.New(fv.Type()).Elem()
+
+	err := setValue(bound, r.arg, format{})
+	if err != nil {
+		return fmt.Errorf("%w: %s=%s: %w", ErrInvalidTag, r.name, r.arg, err)
+	}
+
+	var c int
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c = cmp.Compare(fv.Int(), bound.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		c = cmp.Compare(fv.Uint(), bound.Uint())
+	case reflect.Float32, reflect.Float64:
+		c = cmp.Compare(fv.Float(), bound.Float())
+	default:
+		return fmt.Errorf("%w: %s on %s", ErrInvalidTag, r.name, fv.Type())
+	}
+
+	if r.name == "min" && c < 0 || r.name == "max" && c > 0 {
+		return fmt.Errorf("must be %s %s", boundWord(r.name), r.arg)
+	}
+
+	return nil
+}
+
+func (r rule) checkLen(l int, msg string) error {
+	n, err := strconv.Atoi(r.arg)
+	if err != nil {
+		return fmt.Errorf("%w: %s=%s: %w", ErrInvalidTag, r.name, r.arg, err)
+	}
+
+	if r.name == "min" && l < n || r.name == "max" && l > n {
+		return fmt.Errorf(msg, boundWord(r.name), n)
+	}
+
+	return nil
+}
+
+func boundWord(name string) string {
+	if name == "min" {
+		return "at least"
+	}
+
+	return "at most"
+}
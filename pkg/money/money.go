@@ -0,0 +1,274 @@
+// Package money provides an exact fixed-point monetary amount.
+//
+// An Amount is an integer number of minor units (cents at the default scale
+// of 2). Parsing is strict — no exponents, whitespace, or signs unless
+// allowed — and all arithmetic is overflow-checked, so values never silently
+// wrap or lose precision the way float64 formatting does.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a monetary value in minor units.
+type Amount int64
+
+// ParseOptions controls the accepted syntax of ParseWith.
+type ParseOptions struct {
+	// Scale is the maximum number of fractional digits and the exponent of
+	// the minor unit (2 = cents). Must be in [0, MaxScale].
+	Scale int
+	// AllowNegative accepts a leading '-'.
+	AllowNegative bool
+	// AllowPlus accepts a leading '+'.
+	AllowPlus bool
+}
+
+const (
+	// DefaultScale is the scale used by Parse, String and the JSON/SQL codecs.
+	DefaultScale = 2
+	// MaxScale is the largest supported scale.
+	MaxScale = 18
+)
+
+var (
+	ErrSyntax    = errors.New("money: invalid syntax")
+	ErrPrecision = errors.New("money: too many fractional digits")
+	ErrSign      = errors.New("money: sign not allowed")
+	ErrOverflow  = errors.New("money: value out of range")
+	ErrScale     = errors.New("money: unsupported scale")
+)
+
+// DefaultOptions is used by Parse and UnmarshalJSON/UnmarshalText.
+var DefaultOptions = ParseOptions{Scale: DefaultScale, AllowNegative: true}
+
+// Parse parses s at DefaultScale, accepting an optional leading '-'.
+func Parse(s string) (Amount, error) {
+	return ParseWith(s, DefaultOptions)
+}
+
+// ParseWith parses a decimal string of the form [sign]digits[.digits] into
+// minor units at opts.Scale. The fractional part, when present, must have
+// between 1 and opts.Scale digits; "5.", ".5", "1e3" and " 1" are rejected.
+//
+//nolint:cyclop
+func ParseWith(s string, opts ParseOptions) (Amount, error) {
+	if opts.Scale < 0 || opts.Scale > MaxScale {
+		return 0, fmt.Errorf("%w: %d", ErrScale, opts.Scale)
+	}
+
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrSyntax)
+	}
+
+	neg := false
+
+	switch s[0] {
+	case '-':
+		if !opts.AllowNegative {
+			return 0, fmt.Errorf("%w: %q", ErrSign, s)
+		}
+
+		neg = true
+		s = s[1:]
+	case '+':
+		if !opts.AllowPlus {
+			return 0, fmt.Errorf("%w: %q", ErrSign, s)
+		}
+
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if !isDigits(intPart) || (hasDot && !isDigits(fracPart)) {
+		return 0, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	if len(fracPart) > opts.Scale {
+		return 0, fmt.Errorf("%w: %q has more than %d", ErrPrecision, s, opts.Scale)
+	}
+
+	// Accumulate the magnitude in uint64 so MinInt64 is representable.
+	var mag uint64
+
+	digits := intPart + fracPart + strings.Repeat("0", opts.Scale-len(fracPart))
+	for i := range len(digits) {
+		d := uint64(digits[i] - '0')
+		if mag > (math.MaxUint64-d)/10 {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+
+		mag = mag*10 + d
+	}
+
+	if neg {
+		switch {
+		case mag > uint64(math.MaxInt64)+1:
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+		case mag == uint64(math.MaxInt64)+1:
+			return math.MinInt64, nil
+		default:
+			return -Amount(mag), nil //nolint:gosec // bounds checked above
+		}
+	}
+
+	if mag > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+
+	return Amount(mag), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats a at DefaultScale, e.g. Amount(925) -> "9.25".
+func (a Amount) String() string {
+	return a.Format(DefaultScale)
+}
+
+// Format renders a with exactly scale fractional digits. It is exact for
+// every int64 value.
+func (a Amount) Format(scale int) string {
+	if scale <= 0 {
+		return strconv.FormatInt(int64(a), 10)
+	}
+
+	neg := a < 0
+
+	var mag uint64
+	if neg {
+		mag = uint64(-(a + 1)) + 1 //nolint:gosec // avoids overflow for MinInt64
+	} else {
+		mag = uint64(a)
+	}
+
+	digits := strconv.FormatUint(mag, 10)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	cut := len(digits) - scale
+
+	var b strings.Builder
+
+	b.Grow(len(digits) + 2)
+
+	if neg {
+		b.WriteByte('-')
+	}
+
+	b.WriteString(digits[:cut])
+	b.WriteByte('.')
+	b.WriteString(digits[cut:])
+
+	return b.String()
+}
+
+// Add returns a+b or ErrOverflow.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, fmt.Errorf("%w: %s + %s", ErrOverflow, a, b)
+	}
+
+	return a + b, nil
+}
+
+// Sub returns a-b or ErrOverflow.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, fmt.Errorf("%w: %s - %s", ErrOverflow, a, b)
+	}
+
+	return a - b, nil
+}
+
+// Neg returns -a or ErrOverflow for the minimum value.
+func (a Amount) Neg() (Amount, error) {
+	if a == math.MinInt64 {
+		return 0, fmt.Errorf("%w: -(%s)", ErrOverflow, a)
+	}
+
+	return -a, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using DefaultOptions.
+func (a *Amount) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = v
+
+	return nil
+}
+
+// MarshalJSON encodes a as a JSON string, e.g. "9.25".
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON decodes a JSON string; bare JSON numbers are rejected so no
+// value ever passes through float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return fmt.Errorf("%w: amount must be a JSON string", ErrSyntax)
+	}
+
+	return a.UnmarshalText([]byte(s[1 : len(s)-1]))
+}
+
+// Value implements driver.Valuer; amounts are stored as BIGINT minor units.
+func (a Amount) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+// Scan implements sql.Scanner for BIGINT (and textual integer) columns.
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*a = Amount(v)
+
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", src)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: scan %q: %w", s, err)
+	}
+
+	*a = Amount(v)
+
+	return nil
+}
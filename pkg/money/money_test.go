@@ -0,0 +1,225 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseWith(t *testing.T) {
+	t.Parallel()
+
+	strict := ParseOptions{Scale: 2}
+
+	tests := []struct {
+		name    string
+		in      string
+		opts    ParseOptions
+		want    Amount
+		wantErr error
+	}{
+		{"integer", "10", strict, 1000, nil},
+		{"one_decimal", "10.1", strict, 1010, nil},
+		{"two_decimals", "10.15", strict, 1015, nil},
+		{"leading_zero", "0.05", strict, 5, nil},
+		{"zero", "0", strict, 0, nil},
+		{"max", "92233720368547758.07", strict, math.MaxInt64, nil},
+		{"overflow_int_part", "92233720368547758.08", strict, 0, ErrOverflow},
+		{"overflow_huge", "99999999999999999999999", strict, 0, ErrOverflow},
+		{"too_many_decimals", "1.234", strict, 0, ErrPrecision},
+		{"dot_only", ".", strict, 0, ErrSyntax},
+		{"trailing_dot", "5.", strict, 0, ErrSyntax},
+		{"leading_dot", ".5", strict, 0, ErrSyntax},
+		{"exponent", "1e3", strict, 0, ErrSyntax},
+		{"leading_space", " 1.00", strict, 0, ErrSyntax},
+		{"trailing_space", "1.00 ", strict, 0, ErrSyntax},
+		{"two_dots", "1.0.0", strict, 0, ErrSyntax},
+		{"empty", "", strict, 0, ErrSyntax},
+		{"comma", "1,00", strict, 0, ErrSyntax},
+		{"minus_not_allowed", "-1.00", strict, 0, ErrSign},
+		{"plus_not_allowed", "+1.00", strict, 0, ErrSign},
+		{"minus_allowed", "-1.05", ParseOptions{Scale: 2, AllowNegative: true}, -105, nil},
+		{"plus_allowed", "+1.05", ParseOptions{Scale: 2, AllowPlus: true}, 105, nil},
+		{"sign_only", "-", ParseOptions{Scale: 2, AllowNegative: true}, 0, ErrSyntax},
+		{"min", "-92233720368547758.08", ParseOptions{Scale: 2, AllowNegative: true}, math.MinInt64, nil},
+		{"scale_zero", "42", ParseOptions{Scale: 0}, 42, nil},
+		{"scale_zero_rejects_fraction", "4.2", ParseOptions{Scale: 0}, 0, ErrPrecision},
+		{"scale_three", "1.5", ParseOptions{Scale: 3}, 1500, nil},
+		{"bad_scale", "1", ParseOptions{Scale: 19}, 0, ErrScale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseWith(tt.in, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseWith(%q): want %v, got %v (%d)", tt.in, tt.wantErr, err, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseWith(%q): %v", tt.in, err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("ParseWith(%q): want %d, got %d", tt.in, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in    Amount
+		scale int
+		want  string
+	}{
+		{0, 2, "0.00"},
+		{5, 2, "0.05"},
+		{925, 2, "9.25"},
+		{-925, 2, "-9.25"},
+		{-5, 2, "-0.05"},
+		{1 << 53, 2, "90071992547409.92"},
+		{1<<53 + 1, 2, "90071992547409.93"},
+		{math.MaxInt64, 2, "92233720368547758.07"},
+		{math.MinInt64, 2, "-92233720368547758.08"},
+		{42, 0, "42"},
+		{1500, 3, "1.500"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.Format(tt.scale); got != tt.want {
+			t.Fatalf("Format(%d, %d): want %q, got %q", tt.in, tt.scale, tt.want, got)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Amount(math.MaxInt64).Add(1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Add overflow: got %v", err)
+	}
+
+	if _, err := Amount(math.MinInt64).Sub(1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Sub overflow: got %v", err)
+	}
+
+	if _, err := Amount(math.MinInt64).Neg(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("Neg overflow: got %v", err)
+	}
+
+	sum, err := Amount(1015).Add(-115)
+	if err != nil || sum != 900 {
+		t.Fatalf("Add: want 900, got %d (%v)", sum, err)
+	}
+
+	diff, err := Amount(-1).Sub(math.MaxInt64)
+	if err != nil || diff != math.MinInt64 {
+		t.Fatalf("Sub: got %d (%v)", diff, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(struct {
+		Balance Amount `json:"balance"`
+	}{925})
+	if err != nil || string(b) != `{"balance":"9.25"}` {
+		t.Fatalf("marshal: got %s (%v)", b, err)
+	}
+
+	var v struct {
+		Amount Amount `json:"amount"`
+	}
+
+	err = json.Unmarshal([]byte(`{"amount":"10.15"}`), &v)
+	if err != nil || v.Amount != 1015 {
+		t.Fatalf("unmarshal: got %d (%v)", v.Amount, err)
+	}
+
+	err = json.Unmarshal([]byte(`{"amount":10.15}`), &v)
+	if err == nil {
+		t.Fatalf("unmarshal of a bare number must fail")
+	}
+}
+
+func TestSQL(t *testing.T) {
+	t.Parallel()
+
+	val, err := Amount(925).Value()
+	if err != nil || val != int64(925) {
+		t.Fatalf("Value: got %v (%v)", val, err)
+	}
+
+	for _, src := range []any{int64(925), []byte("925"), "925"} {
+		var a Amount
+
+		err := a.Scan(src)
+		if err != nil || a != 925 {
+			t.Fatalf("Scan(%#v): got %d (%v)", src, a, err)
+		}
+	}
+
+	var a Amount
+	if err := a.Scan(9.25); err == nil {
+		t.Fatalf("Scan(float64) must fail")
+	}
+}
+
+func FuzzParseFormatRoundTrip(f *testing.F) {
+	for _, s := range []string{"0", "9.25", "-0.05", "10.1", "92233720368547758.07", "-92233720368547758.08", ".", "5.", "1e3"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		a, err := Parse(s)
+		if err != nil {
+			return
+		}
+
+		formatted := a.String()
+
+		b, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("Parse(%q) ok but re-parse of %q failed: %v", s, formatted, err)
+		}
+
+		if a != b {
+			t.Fatalf("round trip %q -> %d -> %q -> %d", s, a, formatted, b)
+		}
+
+		if b.String() != formatted {
+			t.Fatalf("format is not canonical: %q vs %q", b.String(), formatted)
+		}
+	})
+}
+
+func FuzzFormatParseRoundTrip(f *testing.F) {
+	for _, n := range []int64{0, 1, -1, 925, 1 << 53, math.MaxInt64, math.MinInt64} {
+		f.Add(n)
+	}
+
+	f.Fuzz(func(t *testing.T, n int64) {
+		for scale := 0; scale <= MaxScale; scale++ {
+			s := Amount(n).Format(scale)
+
+			got, err := ParseWith(s, ParseOptions{Scale: scale, AllowNegative: true})
+			if err != nil {
+				t.Fatalf("ParseWith(%q, scale %d): %v", s, scale, err)
+			}
+
+			if got != Amount(n) {
+				t.Fatalf("scale %d: %d -> %q -> %d", scale, n, s, got)
+			}
+		}
+	})
+}
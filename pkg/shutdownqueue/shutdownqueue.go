@@ -1,49 +1,102 @@
-// Package shutdownqueue provides a process-wide, init()-initialized
-// LIFO shutdown queue for cleanup tasks.
+// Package shutdownqueue provides LIFO shutdown queues for cleanup tasks.
 //
-// Register tasks anywhere (including in your own init() funcs) via Add,
-// and drain them explicitly at the end of main with:
+// A Queue made with New is independent of every other, so tests and programs
+// running several servers can each drain their own. The package-level Add and
+// Shutdown use a process-wide default queue: register tasks anywhere
+// (including in your own init() funcs) and drain them explicitly at the end
+// of main with:
 //
 //	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 //	defer cancel()
 //	defer shutdownqueue.Shutdown(ctx) // or linter-friendly wrapper
 //
 // Tasks run once, in reverse order of registration. Panics are recovered.
-// Shutdown is idempotent and returns an aggregated error via errors.Join.
+// Shutdown is idempotent, reports how each task went and returns an
+// aggregated error via errors.Join.
 package shutdownqueue
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 )
 
 // Task is a shutdown function. It should honor ctx and return an error
 // if it can't finish (or ctx is canceled).
 type Task func(ctx context.Context) error
 
-type queue struct {
+// TaskOption configures a task registered with Add.
+type TaskOption func(*task)
+
+// Queue is a LIFO queue of shutdown tasks. The zero value is not usable; make
+// one with New.
+type Queue struct {
 	mu     sync.Mutex
-	tasks  []Task
+	tasks  []task
 	closed bool
 }
 
-var (
-	q         *queue
-	onceSetup sync.Once
-)
+type task struct {
+	name    string
+	timeout time.Duration
+	fn      Task
+}
+
+// Result is how one task went during Shutdown.
+type Result struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+	// Skipped is true when the task never ran because ctx ended first.
+	Skipped bool
+}
+
+// Report lists the Result of every task in the order they were run.
+type Report []Result
+
+var defaultQueue = New()
+
+// New returns an empty queue.
+func New() *Queue {
+	return &Queue{tasks: make([]task, 0, 8)}
+}
+
+// WithName names the task in the Report and in its errors. Unnamed tasks are
+// called "task N", N being the registration order starting at 1. A name
+// already taken in the queue gets a " (2)", " (3)", ... suffix, so every
+// task keeps its own Report entry.
+func WithName(name string) TaskOption {
+	return func(t *task) {
+		t.name = name
+	}
+}
+
+// WithTimeout gives the task at most d of the time left to Shutdown, so one
+// slow task cannot use up the time of those after it. 0 means no limit of its
+// own.
+func WithTimeout(d time.Duration) TaskOption {
+	return func(t *task) {
+		t.timeout = d
+	}
+}
+
+// Add registers a task on the default queue. See Queue.Add.
+func Add(t Task, opts ...TaskOption) {
+	defaultQueue.Add(t, opts...)
+}
 
-func init() {
-	onceSetup.Do(func() {
-		q = &queue{tasks: make([]Task, 0, 8)}
-	})
+// Shutdown drains the default queue. See Queue.Shutdown.
+func Shutdown(ctx context.Context) (Report, error) {
+	return defaultQueue.Shutdown(ctx)
 }
 
 // Add registers a task to be run on Shutdown, in LIFO order.
 // Safe to call from any goroutine, including in init().
 // If t is nil or shutdown has already started, Add does nothing.
-func Add(t Task) {
+func (q *Queue) Add(t Task, opts ...TaskOption) {
 	if t == nil {
 		return
 	}
@@ -55,24 +108,57 @@ func Add(t Task) {
 		return
 	}
 
-	q.tasks = append(q.tasks, t)
+	tk := task{fn: t}
+	for _, opt := range opts {
+		opt(&tk)
+	}
+
+	if tk.name == "" {
+		tk.name = fmt.Sprintf("task %d", len(q.tasks)+1)
+	}
+
+	tk.name = q.uniqueName(tk.name)
+
+	q.tasks = append(q.tasks, tk)
+}
+
+// uniqueName returns name, suffixed if a queued task already has it. q.mu
+// must be held.
+func (q *Queue) uniqueName(name string) string {
+	taken := func(n string) bool {
+		for _, t := range q.tasks {
+			if t.name == n {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s (%d)", name, i)
+	}
+
+	return unique
 }
 
 // Shutdown drains all registered tasks in LIFO order.
 // It is safe to call multiple times; after the first complete (or partial) run,
 // subsequent calls are no-ops.
 //
-// If ctx is canceled or times out mid-drain, Shutdown stops early and returns
-// an error that includes both the context error and any task errors so far,
-// joined with errors.Join.
-func Shutdown(ctx context.Context) error {
+// If ctx is canceled or times out mid-drain, Shutdown stops early, reports the
+// remaining tasks as skipped and returns an error that includes both the
+// context error and any task errors so far, joined with errors.Join. Task
+// errors are prefixed with the task name.
+func (q *Queue) Shutdown(ctx context.Context) (Report, error) {
 	// Atomically take ownership of tasks and mark closed.
 	q.mu.Lock()
 
 	if q.closed && len(q.tasks) == 0 {
 		q.mu.Unlock()
 
-		return nil
+		return nil, nil
 	}
 
 	q.closed = true
@@ -83,34 +169,84 @@ func Shutdown(ctx context.Context) error {
 
 	q.mu.Unlock()
 
-	var errs []error
+	var (
+		report = make(Report, 0, len(tasks))
+		errs   []error
+	)
 
 	// Run in strict LIFO.
 	for i := len(tasks) - 1; i >= 0; i-- {
 		// Respect cancellation/timeout.
-		select {
-		case <-ctx.Done():
+		if ctx.Err() != nil {
 			errs = append(errs, fmt.Errorf("shutdown canceled: %w", ctx.Err()))
 
-			return errors.Join(errs...)
+			for j := i; j >= 0; j-- {
+				report = append(report, Result{Name: tasks[j].name, Skipped: true})
+			}
+
+			break
+		}
+
+		res := tasks[i].run(ctx)
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+
+		report = append(report, res)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// run runs t with panic safety, within its own timeout if it has one.
+func (t task) run(ctx context.Context) (res Result) {
+	res.Name = t.name
+
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	defer func() {
+		res.Duration = time.Since(start)
+
+		r := recover()
+		if r != nil {
+			res.Err = fmt.Errorf("%s: panic in shutdown task: %v", t.name, r)
+		}
+	}()
+
+	err := t.fn(ctx)
+	if err != nil {
+		res.Err = fmt.Errorf("%s: %w", t.name, err)
+	}
+
+	return res
+}
+
+// LogValue implements slog.LogValuer as a group with one entry per task,
+// holding its duration and, if it failed or was skipped, why.
+func (r Report) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(r))
+
+	for _, res := range r {
+		var fields []slog.Attr
+
+		switch {
+		case res.Skipped:
+			fields = append(fields, slog.Bool("skipped", true))
+		case res.Err != nil:
+			fields = append(fields, slog.Duration("duration", res.Duration), slog.String("error", res.Err.Error()))
 		default:
+			fields = append(fields, slog.Duration("duration", res.Duration))
 		}
 
-		// Run task with panic safety.
-		func(t Task) {
-			defer func() {
-				r := recover()
-				if r != nil {
-					errs = append(errs, fmt.Errorf("panic in shutdown task: %v", r))
-				}
-			}()
-
-			err := t(ctx)
-			if err != nil {
-				errs = append(errs, err)
-			}
-		}(tasks[i])
+		attrs = append(attrs, slog.Attr{Key: res.Name, Value: slog.GroupValue(fields...)})
 	}
 
-	return errors.Join(errs...)
+	return slog.GroupValue(attrs...)
 }
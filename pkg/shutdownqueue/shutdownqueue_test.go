@@ -10,16 +10,11 @@ import (
 	"time"
 )
 
-// resetQueue clears the global queue between tests without fighting init/Once.
+// resetQueue clears the default queue between tests.
 func resetQueue(t *testing.T) {
 	t.Helper()
 	t.Cleanup(func() {
-		q.mu.Lock()
-
-		q.tasks = nil
-		q.closed = false
-
-		q.mu.Unlock()
+		defaultQueue = New()
 	})
 }
 
@@ -31,7 +26,7 @@ func TestAddNilTaskError(t *testing.T) {
 	Add(nil)
 
 	// Verify that Shutdown runs with no tasks and returns nil.
-	err := Shutdown(t.Context())
+	_, err := Shutdown(t.Context())
 	if err != nil {
 		t.Fatalf("expected nil after adding nil task; got %v", err)
 	}
@@ -62,7 +57,7 @@ func TestLIFOOrder(t *testing.T) {
 		Add(makeTask(i))
 	}
 
-	err := Shutdown(t.Context())
+	_, err := Shutdown(t.Context())
 	if err != nil {
 		t.Fatalf("Shutdown error: %v", err)
 	}
@@ -101,7 +96,7 @@ func TestPanicRecoveryIncludedAndContinues(t *testing.T) {
 	Add(panicTask)
 	Add(after)
 
-	shErr := Shutdown(t.Context())
+	_, shErr := Shutdown(t.Context())
 	if shErr == nil {
 		t.Fatalf("expected aggregated error with panic; got nil")
 	}
@@ -149,7 +144,8 @@ func TestAggregatedErrorsAndEarlyCancel(t *testing.T) {
 	errCh := make(chan error, 1)
 
 	go func() {
-		errCh <- Shutdown(ctx)
+		_, err := Shutdown(ctx)
+		errCh <- err
 	}()
 
 	// Wait until gate is running, then cancel so Shutdown stops before B.
@@ -191,7 +187,7 @@ func TestIdempotentAndRunsOnce(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	err := Shutdown(ctx)
+	_, err := Shutdown(ctx)
 	if err != nil {
 		t.Fatalf("Shutdown #1 error: %v", err)
 	}
@@ -200,7 +196,7 @@ func TestIdempotentAndRunsOnce(t *testing.T) {
 		t.Fatalf("expected count=1 after first shutdown; got %d", got)
 	}
 
-	err = Shutdown(ctx)
+	_, err = Shutdown(ctx)
 	if err != nil {
 		t.Fatalf("Shutdown #2 expected nil; got %v", err)
 	}
@@ -233,7 +229,7 @@ func TestAddAfterShutdownReturnsClosed(t *testing.T) {
 	done := make(chan struct{})
 
 	go func() {
-		_ = Shutdown(ctx)
+		_, _ = Shutdown(ctx)
 
 		close(done)
 	}()
@@ -276,7 +272,7 @@ func TestTaskErrorsAreJoinedAndDetectable(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	shErr := Shutdown(ctx)
+	_, shErr := Shutdown(ctx)
 	if shErr == nil {
 		t.Fatalf("expected joined error; got nil")
 	}
@@ -298,13 +294,171 @@ func TestShutdownWithNoTasksIsNil(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	err := Shutdown(ctx)
+	_, err := Shutdown(ctx)
 	if err != nil {
 		t.Fatalf("expected nil when no tasks; got %v", err)
 	}
 
-	err = Shutdown(ctx)
+	_, err = Shutdown(ctx)
 	if err != nil {
 		t.Fatalf("expected nil on repeated shutdown with no tasks; got %v", err)
 	}
 }
+
+func TestQueueReport(t *testing.T) {
+	t.Parallel()
+
+	errClose := errors.New("close failed")
+
+	q := New()
+	q.Add(func(ctx context.Context) error { return nil })
+	q.Add(func(ctx context.Context) error { return errClose }, WithName("db"))
+	q.Add(func(ctx context.Context) error { return nil }, WithName("server"))
+
+	report, err := q.Shutdown(t.Context())
+	if !errors.Is(err, errClose) || !strings.Contains(err.Error(), "db: close failed") {
+		t.Fatalf("Shutdown error = %v, want db: close failed", err)
+	}
+
+	want := []string{"server", "db", "task 1"}
+	if len(report) != len(want) {
+		t.Fatalf("report = %+v, want tasks %v", report, want)
+	}
+
+	for i, name := range want {
+		if report[i].Name != name {
+			t.Errorf("report[%d].Name = %q, want %q", i, report[i].Name, name)
+		}
+
+		if report[i].Skipped {
+			t.Errorf("report[%d] skipped", i)
+		}
+	}
+
+	if !errors.Is(report[1].Err, errClose) || report[0].Err != nil || report[2].Err != nil {
+		t.Errorf("report errors = %v, %v, %v; want only db to fail", report[0].Err, report[1].Err, report[2].Err)
+	}
+}
+
+// The primary and replica pools register under the same name when they share
+// a host; each must keep its own report entry.
+func TestQueueDuplicateNames(t *testing.T) {
+	t.Parallel()
+
+	q := New()
+	for range 3 {
+		q.Add(func(ctx context.Context) error { return nil }, WithName("postgres db"))
+	}
+
+	report, err := q.Shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	want := []string{"postgres db (3)", "postgres db (2)", "postgres db"}
+	for i, name := range want {
+		if report[i].Name != name {
+			t.Errorf("report[%d].Name = %q, want %q", i, report[i].Name, name)
+		}
+	}
+}
+
+func TestQueueTaskTimeout(t *testing.T) {
+	t.Parallel()
+
+	var ranAfter atomic.Bool
+
+	q := New()
+	q.Add(func(ctx context.Context) error {
+		ranAfter.Store(true)
+
+		return nil
+	}, WithName("after"))
+	q.Add(func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}, WithName("stuck"), WithTimeout(10*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := q.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want the stuck task's deadline", err)
+	}
+
+	if !ranAfter.Load() {
+		t.Fatal("task after the timed-out one did not run")
+	}
+
+	if d := report[0].Duration; d < 10*time.Millisecond || d > time.Second {
+		t.Errorf("stuck task took %v, want about its 10ms timeout", d)
+	}
+}
+
+func TestQueueSkipsAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	q := New()
+	q.Add(func(ctx context.Context) error { return nil }, WithName("never"))
+	q.Add(func(ctx context.Context) error {
+		cancel()
+
+		return nil
+	}, WithName("cancels"))
+
+	report, err := q.Shutdown(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Shutdown error = %v, want context.Canceled", err)
+	}
+
+	if len(report) != 2 || report[0].Skipped || !report[1].Skipped || report[1].Name != "never" {
+		t.Errorf("report = %+v, want cancels run and never skipped", report)
+	}
+}
+
+func TestQueuesAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	var ran atomic.Int32
+
+	a, b := New(), New()
+	a.Add(func(ctx context.Context) error {
+		ran.Add(1)
+
+		return nil
+	})
+	b.Add(func(ctx context.Context) error {
+		ran.Add(10)
+
+		return nil
+	})
+
+	_, err := a.Shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if got := ran.Load(); got != 1 {
+		t.Fatalf("after draining a, ran = %d, want 1", got)
+	}
+
+	// b is still open after a is closed.
+	b.Add(func(ctx context.Context) error {
+		ran.Add(100)
+
+		return nil
+	})
+
+	_, err = b.Shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if got := ran.Load(); got != 111 {
+		t.Fatalf("after draining b, ran = %d, want 111", got)
+	}
+}